@@ -5,15 +5,27 @@ import (
 	_ "apis/docs"
 	"apis/internal/entity"
 	"apis/internal/infra/database"
+	"apis/internal/infra/mail"
+	"apis/internal/infra/metrics"
+	"apis/internal/infra/ratelimit"
+	"apis/internal/infra/tracing"
+	"apis/internal/infra/webserver"
 	"apis/internal/infra/webserver/handlers"
+	"apis/internal/infra/webserver/middlewares"
+	"apis/pkg/logger"
+	"apis/pkg/password"
+	"context"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/jwtauth"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"go.uber.org/zap"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
-	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 // @title API de Produtos
@@ -30,6 +42,9 @@ import (
 // @securityDefinitions.apiKey ApiKeyAuth
 // @in header
 // @name Authorization
+// @securityDefinitions.apiKey APIKeyHeader
+// @in header
+// @name X-API-Key
 // @tokenUrl http://localhost:8000/auth/generate_token
 func main() {
 	// Carrega a configuração
@@ -38,69 +53,351 @@ func main() {
 		panic(err)
 	}
 
+	// Configura o logger da aplicação
+	log, err := logger.New(conf.LogLevel, conf.LogFormat)
+	if err != nil {
+		panic(err)
+	}
+	defer func() { _ = log.Sync() }()
+	zap.ReplaceGlobals(log)
+
+	// Configura o exportador de traces
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:     conf.TracingExporter,
+		OTLPEndpoint: conf.TracingOTLPEndpoint,
+		OTLPInsecure: conf.TracingOTLPInsecure,
+		FilePath:     conf.TracingFilePath,
+		SampleRatio:  conf.TracingSampleRatio,
+	})
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Error("failed to shutdown tracing", zap.Error(err))
+		}
+	}()
+
 	// Conecta-se ao banco de dados SQLite
-	db, err := gorm.Open(sqlite.Open("test.db"), &gorm.Config{})
+	db, err := gorm.Open(sqlite.Open("test.db"), &gorm.Config{
+		Logger: database.NewGormLogger(database.DefaultSlowQueryThreshold),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	// Expõe métricas e spans das consultas e métricas do pool de conexões
+	err = db.Use(metrics.GormPlugin{})
+	if err != nil {
+		panic(err)
+	}
+	err = db.Use(tracing.GormPlugin{})
+	if err != nil {
+		panic(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		panic(err)
+	}
+	defer func() {
+		if err := sqlDB.Close(); err != nil {
+			log.Error("failed to close database", zap.Error(err))
+		}
+	}()
+	err = metrics.RegisterDB(sqlDB, "main")
 	if err != nil {
 		panic(err)
 	}
 
 	// Executa migrações automáticas
-	err = db.AutoMigrate(&entity.User{}, &entity.Product{})
+	err = db.AutoMigrate(database.Models()...)
+	if err != nil {
+		panic(err)
+	}
+
+	// Cria o tenant padrão e atribui a ele os registros anteriores aos tenants
+	defaultTenantName := "Default"
+	if conf.DefaultTenantName != "" {
+		defaultTenantName = conf.DefaultTenantName
+	}
+	defaultTenant, err := database.EnsureDefaultTenant(db, defaultTenantName)
+	if err != nil {
+		panic(err)
+	}
+
+	// Audita as alterações em produtos e usuários
+	err = database.RegisterAuditCallbacks(db, "products", "users")
+	if err != nil {
+		panic(err)
+	}
+	// O log de conformidade não pode ser alterado
+	err = database.RegisterComplianceCallbacks(db)
 	if err != nil {
 		panic(err)
 	}
 
+	// Algoritmo de hash de senhas. Hashes do outro algoritmo continuam válidos
+	// e são refeitos no próximo login.
+	bcryptHasher := password.NewBcrypt(conf.PasswordBcryptCost)
+	argon2Hasher := password.NewArgon2id(uint32(conf.PasswordArgon2Memory), uint32(conf.PasswordArgon2Iterations), uint8(conf.PasswordArgon2Parallelism))
+	switch conf.PasswordHashAlgorithm {
+	case password.AlgorithmBcrypt:
+		entity.PasswordHasher = password.NewService(bcryptHasher, argon2Hasher)
+	case password.AlgorithmArgon2id, "":
+		entity.PasswordHasher = password.NewService(argon2Hasher, bcryptHasher)
+	default:
+		panic(password.ErrUnknownAlgorithm)
+	}
+
 	// Inicializa os handlers
-	productHandler := handlers.NewProductHandler(database.NewProduct(db))
-	userHandler := handlers.NewUserHandler(database.NewUser(db))
+	auditDB := database.NewAudit(db)
+	productDB := database.NewProduct(db)
+	productPriceDB := database.NewProductPrice(db)
+	productHandler := handlers.NewProductHandler(productDB, auditDB, productPriceDB)
+	if conf.ProductBulkMaxBatchSize > 0 {
+		productHandler.BulkMaxBatchSize = conf.ProductBulkMaxBatchSize
+	}
+	productRevisionHandler := handlers.NewProductRevisionHandler(productDB, database.NewProductRevision(db), productPriceDB)
+	var mailer mail.Mailer = mail.NewLogMailer()
+	if conf.Mailer == "smtp" {
+		mailer = mail.NewSMTPMailer(conf.SMTPHost, conf.SMTPPort, conf.SMTPUsername, conf.SMTPPassword, conf.MailFrom)
+	}
+	sessionDB := database.NewSession(db)
+	userHandler := handlers.NewUserHandler(database.NewUser(db), database.NewUserToken(db), database.NewRecoveryCode(db), sessionDB, mailer)
+	userHandler.PasswordResetURL = conf.PasswordResetURL
+	if conf.PasswordResetTTL > 0 {
+		userHandler.PasswordResetTTL = time.Second * time.Duration(conf.PasswordResetTTL)
+	}
+	userHandler.EmailVerificationRequired = conf.EmailVerificationRequired
+	userHandler.EmailVerificationURL = conf.EmailVerificationURL
+	if conf.EmailVerificationTTL > 0 {
+		userHandler.EmailVerificationTTL = time.Second * time.Duration(conf.EmailVerificationTTL)
+	}
+	if conf.MFAChallengeTTL > 0 {
+		userHandler.MFAChallengeTTL = time.Second * time.Duration(conf.MFAChallengeTTL)
+	}
+	if conf.MFAIssuer != "" {
+		userHandler.MFAIssuer = conf.MFAIssuer
+	}
+	if conf.PasswordMinLength > 0 {
+		userHandler.PasswordPolicy = password.NewPolicy(conf.PasswordMinLength)
+	}
+	if conf.PasswordBreachedList != "" {
+		if err := userHandler.PasswordPolicy.LoadBreachedList(conf.PasswordBreachedList); err != nil {
+			panic(err)
+		}
+	}
+	if conf.EmailVerificationResendRequests > 0 {
+		userHandler.VerificationResendLimit = ratelimit.Limit{
+			Requests: conf.EmailVerificationResendRequests,
+			Period:   time.Second * time.Duration(conf.EmailVerificationResendPeriod),
+		}
+	}
+	if conf.LoginMaxAttempts > 0 {
+		userHandler.MaxLoginAttempts = conf.LoginMaxAttempts
+	}
+	if conf.LoginLockout > 0 {
+		userHandler.LoginLockout = time.Second * time.Duration(conf.LoginLockout)
+	}
+	if conf.LoginBackoffBaseMs > 0 {
+		userHandler.LoginBackoff = ratelimit.NewBackoff(
+			time.Millisecond*time.Duration(conf.LoginBackoffBaseMs),
+			time.Millisecond*time.Duration(conf.LoginBackoffMaxMs),
+			time.Second*time.Duration(conf.LoginBackoffWindow),
+		)
+	}
+	auditHandler := handlers.NewAuditHandler(auditDB)
+	apiKeyDB := database.NewAPIKey(db)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyDB)
+	oauthClientDB := database.NewOAuthClient(db)
+	oauthHandler := handlers.NewOAuthHandler(oauthClientDB, userHandler)
+	privacyHandler := handlers.NewPrivacyHandler(database.NewUser(db), sessionDB, apiKeyDB, auditDB, database.NewCompliance(db))
+	tenantHandler := handlers.NewTenantHandler(database.NewTenant(db))
+	authenticate := middlewares.Authenticate(conf.JwtAuth, apiKeyDB, database.NewUser(db), sessionDB, oauthClientDB, defaultTenant.ID)
+	// O tenant das rotas públicas vem do header X-Tenant
+	resolveTenant := middlewares.ResolveTenant(database.NewTenant(db), defaultTenant.ID)
+	healthHandler := handlers.NewHealthHandler(
+		handlers.HealthCheck{Name: "database", Check: sqlDB.PingContext},
+		handlers.HealthCheck{Name: "migrations", Check: func(ctx context.Context) error {
+			return database.CheckSchema(db.WithContext(ctx))
+		}},
+		handlers.HealthCheck{Name: "jwt", Check: func(context.Context) error {
+			return conf.CheckJWT()
+		}},
+	)
+	idempotencyDB := database.NewIdempotency(db)
+	idempotency := middlewares.Idempotency(idempotencyDB, time.Second*time.Duration(conf.IdempotencyTTL), time.Second*time.Duration(conf.IdempotencyLock))
+
+	// Limites de requisições por grupo de rotas
+	rateLimitStore := ratelimit.NewMemoryStore()
+	authRateLimit := middlewares.RateLimit(rateLimitStore, "auth", ratelimit.Limit{
+		Requests: conf.RateLimitAuthRequests,
+		Period:   time.Second * time.Duration(conf.RateLimitAuthPeriod),
+	})
+	productsRateLimit := middlewares.RateLimit(rateLimitStore, "products", ratelimit.Limit{
+		Requests: conf.RateLimitProductsRequests,
+		Period:   time.Second * time.Duration(conf.RateLimitProductsPeriod),
+	})
+	usersRateLimit := middlewares.RateLimit(rateLimitStore, "users", ratelimit.Limit{
+		Requests: conf.RateLimitUsersRequests,
+		Period:   time.Second * time.Duration(conf.RateLimitUsersPeriod),
+	})
 
 	// Cria um roteador Chi
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
+	r.Use(middlewares.RequestID)
+	r.Use(middlewares.Tracing)
+	r.Use(middlewares.AccessLog(log))
+	r.Use(middlewares.Metrics)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.WithValue("jwtAuth", conf.JwtAuth))
 	r.Use(middleware.WithValue("jwtExpiresIn", conf.JWTExpiresIn))
+	// Escopos exigidos por rota
+	productsRead := middlewares.RequireScope(entity.ScopeProductsRead)
+	productsWrite := middlewares.RequireScope(entity.ScopeProductsWrite)
+	usersRead := middlewares.RequireScope(entity.ScopeUsersRead)
+	usersWrite := middlewares.RequireScope(entity.ScopeUsersWrite)
+	usersAdmin := middlewares.RequireScope(entity.ScopeUsersAdmin)
+
 	// Rotas para produtos
 	r.Route("/products", func(r chi.Router) {
-		r.Use(ZapLoggerMiddleware(zap.NewExample()))
-		r.Use(jwtauth.Verifier(conf.JwtAuth))
-		r.Use(jwtauth.Authenticator)
-		r.Post("/", productHandler.Create)
-		r.Get("/", productHandler.GetProducts)
-		r.Get("/{id}", productHandler.GetProduct)
-		r.Put("/{id}", productHandler.UpdateProduct)
-		r.Delete("/{id}", productHandler.DeleteProduct)
+		r.Use(authenticate)
+		r.Use(middlewares.Actor)
+		r.Use(productsRateLimit)
+		r.With(productsWrite, idempotency).Post("/", productHandler.Create)
+		r.With(productsWrite, idempotency).Post("/bulk", productHandler.BulkProducts)
+		r.With(productsRead).Get("/", productHandler.GetProducts)
+		r.With(productsRead).Get("/{id}", productHandler.GetProduct)
+		r.With(productsRead).Get("/{id}/history", productHandler.GetProductHistory)
+		r.With(productsRead).Get("/{id}/prices", productHandler.GetProductPrices)
+		r.With(productsRead).Get("/{id}/revisions", productRevisionHandler.GetRevisions)
+		r.With(productsRead).Get("/{id}/revisions/diff", productRevisionHandler.DiffRevisions)
+		r.With(productsRead).Get("/{id}/revisions/{n}", productRevisionHandler.GetRevision)
+		r.With(productsWrite).Post("/{id}/revisions/{n}/revert", productRevisionHandler.RevertRevision)
+		r.With(productsWrite).Put("/{id}", productHandler.UpdateProduct)
+		r.With(productsWrite).Delete("/{id}", productHandler.DeleteProduct)
 	})
 
 	// Rotas para usuários
 	r.Route("/users", func(r chi.Router) {
-		r.Use(jwtauth.Verifier(conf.JwtAuth))
-		r.Use(jwtauth.Authenticator)
-		r.Patch("/{id}", userHandler.UpdateUser)
-		r.Delete("/{id}", userHandler.DeleteUser)
-		r.Get("/", userHandler.GetUsers)
-		r.Get("/{id}", userHandler.GetUser)
+		r.Use(authenticate)
+		r.Use(middlewares.Actor)
+		r.Use(usersRateLimit)
+		r.With(usersRead).Get("/me", userHandler.GetMe)
+		r.With(usersWrite).Patch("/me", userHandler.UpdateMe)
+		r.With(usersWrite).Post("/me/password", userHandler.ChangePassword)
+		r.With(usersRead).Get("/me/sessions", userHandler.GetSessions)
+		r.With(usersWrite).Delete("/me/sessions", userHandler.DeleteSessions)
+		r.With(usersWrite).Delete("/me/sessions/{sessionID}", userHandler.DeleteSession)
+		r.Route("/me/api-keys", apiKeyRoutes(apiKeyHandler))
+		r.With(usersWrite).Post("/me/mfa/totp", userHandler.EnrollTOTP)
+		r.With(usersWrite).Post("/me/mfa/totp/confirm", userHandler.ConfirmTOTP)
+		r.With(usersWrite).Patch("/{id}", userHandler.UpdateUser)
+		r.With(usersAdmin, middlewares.RequireRole(entity.RoleAdmin)).Post("/{id}/unlock", userHandler.UnlockUser)
+		r.With(usersAdmin, middlewares.RequireRole(entity.RoleAdmin)).Put("/{id}/status", userHandler.UpdateUserStatus)
+		r.With(usersAdmin, middlewares.RequireRole(entity.RoleAdmin)).Post("/{id}/erase", privacyHandler.EraseUser)
+		r.With(usersRead).Get("/{id}/export", privacyHandler.ExportUser)
+		r.With(usersWrite).Delete("/{id}", userHandler.DeleteUser)
+		r.With(usersAdmin, middlewares.RequireRole(entity.RoleAdmin)).Get("/", userHandler.GetUsers)
+		r.With(usersRead).Get("/{id}", userHandler.GetUser)
+	})
+	r.With(authRateLimit, resolveTenant, idempotency, middlewares.Actor).Post("/users", userHandler.Create)
+	r.With(authRateLimit, resolveTenant).Post("/users/auth/generate_token", userHandler.GetJwt)
+	r.With(authRateLimit).Post("/users/auth/mfa/verify", userHandler.VerifyMFA)
+	r.With(authRateLimit, resolveTenant).Post("/users/auth/password/forgot", userHandler.ForgotPassword)
+	r.With(authRateLimit).Post("/users/auth/password/reset", userHandler.ResetPassword)
+	r.With(authRateLimit).Get("/users/auth/verify", userHandler.VerifyEmail)
+	r.With(authRateLimit, resolveTenant).Post("/users/auth/verify/resend", userHandler.ResendVerification)
+
+	// Rotas OAuth2
+	r.With(authRateLimit).Post("/oauth/token", oauthHandler.Token)
+
+	// Rotas administrativas
+	r.Route("/oauth/clients", func(r chi.Router) {
+		r.Use(authenticate)
+		r.Use(usersAdmin)
+		r.Use(middlewares.RequireRole(entity.RoleAdmin))
+		r.Use(usersRateLimit)
+		r.Post("/", oauthHandler.CreateClient)
+		r.Get("/", oauthHandler.GetClients)
+		r.Delete("/{id}", oauthHandler.DeleteClient)
+	})
+
+	r.Route("/audit", func(r chi.Router) {
+		r.Use(authenticate)
+		r.Use(usersAdmin)
+		r.Use(middlewares.RequireRole(entity.RoleAdmin))
+		r.Use(usersRateLimit)
+		r.Get("/", auditHandler.GetAudit)
+	})
+
+	r.Route("/compliance", func(r chi.Router) {
+		r.Use(authenticate)
+		r.Use(usersAdmin)
+		r.Use(middlewares.RequireRole(entity.RoleAdmin))
+		r.Use(usersRateLimit)
+		r.Get("/", privacyHandler.GetComplianceLog)
+	})
+
+	// Os tenants são administrados pelo tenant padrão
+	r.Route("/tenants", func(r chi.Router) {
+		r.Use(authenticate)
+		r.Use(usersAdmin)
+		r.Use(middlewares.RequireRole(entity.RoleAdmin))
+		r.Use(middlewares.RequireTenant(defaultTenant.ID))
+		r.Use(usersRateLimit)
+		r.Post("/", tenantHandler.CreateTenant)
+		r.Get("/", tenantHandler.GetTenants)
+	})
+
+	r.Get("/healthz", healthHandler.Healthz)
+	r.Get("/readyz", healthHandler.Readyz)
+	r.Handle("/metrics", promhttp.Handler())
+	r.Get("/docs/*", httpSwagger.Handler(httpSwagger.URL("/docs/doc.json")))
+	// Inicia o servidor HTTP e o encerra ao receber SIGINT ou SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Remove as chaves de idempotência expiradas até o encerramento
+	go middlewares.CleanupIdempotency(logger.WithContext(ctx, log), idempotencyDB, time.Second*time.Duration(conf.IdempotencyCleanup))
+
+	srv := webserver.NewServer(webserver.ServerConfig{
+		Port:              conf.WebServerPort,
+		ReadTimeout:       time.Second * time.Duration(conf.WebServerReadTimeout),
+		ReadHeaderTimeout: time.Second * time.Duration(conf.WebServerReadHeaderTimeout),
+		WriteTimeout:      time.Second * time.Duration(conf.WebServerWriteTimeout),
+		IdleTimeout:       time.Second * time.Duration(conf.WebServerIdleTimeout),
+		MaxHeaderBytes:    conf.WebServerMaxHeaderBytes,
+	}, r)
+
+	log.Info("server started", zap.String("addr", srv.Addr))
+	err = webserver.Run(ctx, srv, webserver.ShutdownConfig{
+		DrainDelay: time.Second * time.Duration(conf.WebServerDrainDelay),
+		Timeout:    time.Second * time.Duration(conf.WebServerShutdownTimeout),
+		OnDrain: func() {
+			log.Info("shutting down server")
+			healthHandler.SetDraining()
+		},
 	})
-	r.Post("/users", userHandler.Create)
-	r.Post("/users/auth/generate_token", userHandler.GetJwt)
-	r.Get("/docs/*", httpSwagger.Handler(httpSwagger.URL("http://localhost:8000/docs/doc.json")))
-	// Inicia o servidor HTTP na porta 8000
-	err = http.ListenAndServe(":8000", r)
 	if err != nil {
-		panic(err)
+		log.Error("server stopped with error", zap.Error(err))
+		return
 	}
+	log.Info("server stopped")
 }
 
-func ZapLoggerMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			logger.Info("request started",
-				zap.String("method", r.Method),
-				zap.String("url", r.URL.String()),
-				zap.String("remote_addr", r.RemoteAddr),
-			)
-			next.ServeHTTP(w, r)
-			logger.Info("request completed")
-		})
+// apiKeyRoutes registra as rotas das chaves de API do usuário autenticado.
+// Ler as chaves exige users:read e criá-las ou alterá-las exige users:write.
+func apiKeyRoutes(apiKeyHandler *handlers.APIKeyHandler) func(chi.Router) {
+	usersRead := middlewares.RequireScope(entity.ScopeUsersRead)
+	usersWrite := middlewares.RequireScope(entity.ScopeUsersWrite)
+	return func(r chi.Router) {
+		r.With(usersWrite).Post("/", apiKeyHandler.CreateAPIKey)
+		r.With(usersRead).Get("/", apiKeyHandler.GetAPIKeys)
+		r.With(usersRead).Get("/{keyID}", apiKeyHandler.GetAPIKey)
+		r.With(usersWrite).Patch("/{keyID}", apiKeyHandler.UpdateAPIKey)
+		r.With(usersWrite).Delete("/{keyID}", apiKeyHandler.DeleteAPIKey)
 	}
 }
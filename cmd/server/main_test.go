@@ -0,0 +1,45 @@
+package main
+
+import (
+	"apis/internal/entity"
+	"apis/internal/infra/webserver/handlers"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyRoutes_RequireUsersScopes(t *testing.T) {
+	ja := jwtauth.New("HS256", []byte("secret"), nil)
+	r := chi.NewRouter()
+	r.Use(jwtauth.Verifier(ja))
+	r.Route("/users/me/api-keys", apiKeyRoutes(&handlers.APIKeyHandler{}))
+
+	routes := []struct {
+		method string
+		path   string
+		scope  string
+	}{
+		{http.MethodPost, "/users/me/api-keys", entity.ScopeUsersWrite},
+		{http.MethodGet, "/users/me/api-keys", entity.ScopeUsersRead},
+		{http.MethodGet, "/users/me/api-keys/key-1", entity.ScopeUsersRead},
+		{http.MethodPatch, "/users/me/api-keys/key-1", entity.ScopeUsersWrite},
+		{http.MethodDelete, "/users/me/api-keys/key-1", entity.ScopeUsersWrite},
+	}
+	for _, route := range routes {
+		t.Run(route.method+" "+route.path, func(t *testing.T) {
+			for _, scope := range []string{entity.ScopeProductsRead, entity.ScopeProductsRead + " " + entity.ScopeProductsWrite} {
+				_, token, _ := ja.Encode(map[string]interface{}{"sub": "user-1", "scope": scope})
+				req := httptest.NewRequest(route.method, route.path, nil)
+				req.Header.Set("Authorization", "Bearer "+token)
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				assert.Equal(t, http.StatusForbidden, w.Code, "token without users scopes should be rejected")
+				assert.Contains(t, w.Header().Get("WWW-Authenticate"), route.scope)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package configs
 
 import (
+	"errors"
 	"fmt"
 	"github.com/go-chi/jwtauth"
 	"github.com/spf13/viper"
@@ -17,6 +18,70 @@ type Conf struct {
 	JWTSecret     string `mapstructure:"JWT_SECRET"`
 	JWTExpiresIn  int    `mapstructure:"JWT_EXPIRES_IN"`
 	JwtAuth       *jwtauth.JWTAuth
+
+	ProductBulkMaxBatchSize int `mapstructure:"PRODUCT_BULK_MAX_BATCH_SIZE"`
+	IdempotencyTTL          int `mapstructure:"IDEMPOTENCY_TTL"`
+	IdempotencyLock         int `mapstructure:"IDEMPOTENCY_LOCK_TIMEOUT"`
+	IdempotencyCleanup      int `mapstructure:"IDEMPOTENCY_CLEANUP_INTERVAL"`
+
+	LogLevel  string `mapstructure:"LOG_LEVEL"`
+	LogFormat string `mapstructure:"LOG_FORMAT"`
+
+	TracingExporter     string  `mapstructure:"TRACING_EXPORTER"`
+	TracingOTLPEndpoint string  `mapstructure:"TRACING_OTLP_ENDPOINT"`
+	TracingOTLPInsecure bool    `mapstructure:"TRACING_OTLP_INSECURE"`
+	TracingFilePath     string  `mapstructure:"TRACING_FILE_PATH"`
+	TracingSampleRatio  float64 `mapstructure:"TRACING_SAMPLE_RATIO"`
+
+	WebServerReadTimeout       int `mapstructure:"WEB_SERVER_READ_TIMEOUT"`
+	WebServerReadHeaderTimeout int `mapstructure:"WEB_SERVER_READ_HEADER_TIMEOUT"`
+	WebServerWriteTimeout      int `mapstructure:"WEB_SERVER_WRITE_TIMEOUT"`
+	WebServerIdleTimeout       int `mapstructure:"WEB_SERVER_IDLE_TIMEOUT"`
+	WebServerMaxHeaderBytes    int `mapstructure:"WEB_SERVER_MAX_HEADER_BYTES"`
+	WebServerDrainDelay        int `mapstructure:"WEB_SERVER_DRAIN_DELAY"`
+	WebServerShutdownTimeout   int `mapstructure:"WEB_SERVER_SHUTDOWN_TIMEOUT"`
+
+	RateLimitAuthRequests     int `mapstructure:"RATE_LIMIT_AUTH_REQUESTS"`
+	RateLimitAuthPeriod       int `mapstructure:"RATE_LIMIT_AUTH_PERIOD"`
+	RateLimitProductsRequests int `mapstructure:"RATE_LIMIT_PRODUCTS_REQUESTS"`
+	RateLimitProductsPeriod   int `mapstructure:"RATE_LIMIT_PRODUCTS_PERIOD"`
+	RateLimitUsersRequests    int `mapstructure:"RATE_LIMIT_USERS_REQUESTS"`
+	RateLimitUsersPeriod      int `mapstructure:"RATE_LIMIT_USERS_PERIOD"`
+
+	LoginMaxAttempts   int `mapstructure:"LOGIN_MAX_ATTEMPTS"`
+	LoginLockout       int `mapstructure:"LOGIN_LOCKOUT"`
+	LoginBackoffBaseMs int `mapstructure:"LOGIN_BACKOFF_BASE_MS"`
+	LoginBackoffMaxMs  int `mapstructure:"LOGIN_BACKOFF_MAX_MS"`
+	LoginBackoffWindow int `mapstructure:"LOGIN_BACKOFF_WINDOW"`
+
+	PasswordResetTTL int    `mapstructure:"PASSWORD_RESET_TTL"`
+	PasswordResetURL string `mapstructure:"PASSWORD_RESET_URL"`
+
+	Mailer       string `mapstructure:"MAILER"`
+	MailFrom     string `mapstructure:"MAIL_FROM"`
+	SMTPHost     string `mapstructure:"SMTP_HOST"`
+	SMTPPort     string `mapstructure:"SMTP_PORT"`
+	SMTPUsername string `mapstructure:"SMTP_USERNAME"`
+	SMTPPassword string `mapstructure:"SMTP_PASSWORD"`
+
+	EmailVerificationRequired       bool   `mapstructure:"EMAIL_VERIFICATION_REQUIRED"`
+	EmailVerificationTTL            int    `mapstructure:"EMAIL_VERIFICATION_TTL"`
+	EmailVerificationURL            string `mapstructure:"EMAIL_VERIFICATION_URL"`
+	EmailVerificationResendRequests int    `mapstructure:"EMAIL_VERIFICATION_RESEND_REQUESTS"`
+	EmailVerificationResendPeriod   int    `mapstructure:"EMAIL_VERIFICATION_RESEND_PERIOD"`
+
+	MFAChallengeTTL int    `mapstructure:"MFA_CHALLENGE_TTL"`
+	MFAIssuer       string `mapstructure:"MFA_ISSUER"`
+
+	PasswordHashAlgorithm     string `mapstructure:"PASSWORD_HASH_ALGORITHM"`
+	PasswordBcryptCost        int    `mapstructure:"PASSWORD_BCRYPT_COST"`
+	PasswordArgon2Memory      int    `mapstructure:"PASSWORD_ARGON2_MEMORY"`
+	PasswordArgon2Iterations  int    `mapstructure:"PASSWORD_ARGON2_ITERATIONS"`
+	PasswordArgon2Parallelism int    `mapstructure:"PASSWORD_ARGON2_PARALLELISM"`
+	PasswordMinLength         int    `mapstructure:"PASSWORD_MIN_LENGTH"`
+	PasswordBreachedList      string `mapstructure:"PASSWORD_BREACHED_LIST"`
+
+	DefaultTenantName string `mapstructure:"DEFAULT_TENANT_NAME"`
 }
 
 func LoadConfig(path string) (*Conf, error) {
@@ -40,3 +105,13 @@ func LoadConfig(path string) (*Conf, error) {
 
 	return cfg, nil
 }
+
+// CheckJWT verifica se o material da chave JWT foi carregado e permite assinar
+// tokens.
+func (c *Conf) CheckJWT() error {
+	if c.JWTSecret == "" || c.JwtAuth == nil {
+		return errors.New("jwt secret is not loaded")
+	}
+	_, _, err := c.JwtAuth.Encode(map[string]interface{}{})
+	return err
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 type CreateProductInput struct {
 	Name        string  `json:"name"`
 	Description string  `json:"description"`
@@ -20,7 +22,84 @@ type UpdateUserInput struct {
 	CreteUserInput
 }
 
+type UpdateUserStatusInput struct {
+	Status string `json:"status" enums:"active,suspended,deactivated"`
+	Reason string `json:"reason"`
+}
+
+type EraseUserInput struct {
+	Reason string `json:"reason"`
+}
+
+type UpdateMeInput struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
 type LoginInput struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Scope    string `json:"scope,omitempty" example:"products:read products:write"`
+}
+
+type ForgotPasswordInput struct {
+	Email string `json:"email"`
+}
+
+type ResetPasswordInput struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type ResendVerificationInput struct {
+	Email string `json:"email"`
+}
+
+type TOTPCodeInput struct {
+	Code string `json:"code"`
+}
+
+type MFAVerifyInput struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+	Scope        string `json:"scope,omitempty" example:"products:read products:write"`
+}
+
+type BulkProductOperationInput struct {
+	Operation   string  `json:"operation" enums:"create,update,delete"`
+	ID          string  `json:"id,omitempty"`
+	Name        string  `json:"name,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Price       float64 `json:"price,omitempty"`
+}
+
+type BulkProductInput struct {
+	Mode       string                      `json:"mode" enums:"atomic,best_effort" default:"atomic"`
+	Operations []BulkProductOperationInput `json:"operations"`
+}
+
+type CreateAPIKeyInput struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type UpdateAPIKeyInput struct {
+	Name string `json:"name"`
+}
+
+type CreateOAuthClientInput struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+type CreateTenantInput struct {
+	Name string `json:"name"`
+	Slug string `json:"slug" example:"acme"`
 }
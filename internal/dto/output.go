@@ -1,7 +1,86 @@
 package dto
 
+import (
+	"apis/internal/entity"
+	"time"
+)
+
 type GetJWTOutput struct {
+	AccessToken string `json:"access_token,omitempty"`
+	MFARequired bool   `json:"mfa_required,omitempty"`
+	MFAToken    string `json:"mfa_token,omitempty"`
+}
+
+type TOTPEnrollmentOutput struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+	QRCodePNG  []byte `json:"qr_code_png" swaggertype:"string" format:"base64"`
+}
+
+type RecoveryCodesOutput struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type OAuthTokenOutput struct {
 	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+}
+
+type OAuthErrorOutput struct {
+	Error            string `json:"error" enums:"invalid_request,invalid_client,invalid_grant,unauthorized_client,unsupported_grant_type,invalid_scope"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type CreateOAuthClientOutput struct {
+	ID           string    `json:"id"`
+	ClientID     string    `json:"client_id"`
+	ClientSecret string    `json:"client_secret"`
+	Name         string    `json:"name"`
+	Scopes       []string  `json:"scopes"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type CreateAPIKeyOutput struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Key       string     `json:"key"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type SessionOutput struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+type RevokeSessionsOutput struct {
+	Revoked int64 `json:"revoked"`
+}
+
+type UserListOutput struct {
+	Items []*entity.User `json:"items"`
+	Total int64          `json:"total"`
+	Page  int            `json:"page"`
+	Limit int            `json:"limit"`
+}
+
+// UserExportOutput reúne os dados ligados a um usuário para atender pedidos
+// de portabilidade. No formato ZIP, cada campo vira um arquivo JSON.
+type UserExportOutput struct {
+	ExportedAt   time.Time            `json:"exported_at"`
+	Profile      *entity.User         `json:"profile"`
+	Sessions     []*entity.Session    `json:"sessions"`
+	APIKeys      []*entity.APIKey     `json:"api_keys"`
+	AuditEntries []*entity.AuditEntry `json:"audit_entries"`
 }
 
 type CreateUserOutput struct {
@@ -11,15 +90,50 @@ type CreateUserOutput struct {
 }
 
 type CreateProductOutput struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
-	Description string  `json:"description"`
+	ID             string  `json:"id"`
+	Name           string  `json:"name"`
+	Price          float64 `json:"price"`
+	LowestPrice30d float64 `json:"lowest_price_30d"`
+	Description    string  `json:"description"`
 }
 
 type UpdateProductOutput struct {
-	ID          string  `json:"id"`
-	Name        string  `json:"name"`
-	Price       float64 `json:"price"`
-	Description string  `json:"description"`
+	ID             string  `json:"id"`
+	Name           string  `json:"name"`
+	Price          float64 `json:"price"`
+	LowestPrice30d float64 `json:"lowest_price_30d"`
+	Description    string  `json:"description"`
+}
+
+type BulkProductOperationOutput struct {
+	Index     int    `json:"index"`
+	Operation string `json:"operation"`
+	ID        string `json:"id,omitempty"`
+	Status    string `json:"status" enums:"created,updated,deleted,failed,skipped"`
+	Error     string `json:"error,omitempty"`
+}
+
+type BulkProductOutput struct {
+	Mode      string                       `json:"mode"`
+	Succeeded int                          `json:"succeeded"`
+	Failed    int                          `json:"failed"`
+	Results   []BulkProductOperationOutput `json:"results"`
+}
+
+type ProductRevisionDiffOutput struct {
+	From    int                 `json:"from"`
+	To      int                 `json:"to"`
+	Changes entity.AuditChanges `json:"changes"`
+}
+
+type HealthCheckOutput struct {
+	Name       string  `json:"name"`
+	Status     string  `json:"status" enums:"ok,fail"`
+	DurationMs float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+type HealthOutput struct {
+	Status string              `json:"status" enums:"ok,fail"`
+	Checks []HealthCheckOutput `json:"checks,omitempty"`
 }
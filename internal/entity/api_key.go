@@ -0,0 +1,94 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"strings"
+	"time"
+)
+
+const (
+	APIKeyPrefix = "pk"
+
+	// APIKeyIDClaim identifica os tokens gerados a partir de uma chave de API.
+	APIKeyIDClaim = "api_key_id"
+
+	apiKeyPrefixBytes    = 5
+	apiKeySecretBytes    = 32
+	apiKeyTouchThreshold = time.Minute
+)
+
+var ErrAPIKeyNameIsRequired = entity.NewError("name is required")
+
+// APIKey autentica integrações em nome do usuário. A chave tem o formato
+// pk_<prefixo>_<segredo>; o prefixo identifica a chave e só o hash da chave
+// completa é persistido.
+type APIKey struct {
+	ID         entity.ID  `json:"id"`
+	UserID     entity.ID  `json:"user_id" gorm:"index"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix" gorm:"uniqueIndex"`
+	KeyHash    string     `json:"-"`
+	Scopes     Scopes     `json:"scopes" gorm:"type:text"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// NewAPIKey gera a chave e devolve a entidade junto com o valor que deve ser
+// mostrado uma única vez ao usuário.
+func NewAPIKey(userID entity.ID, name string, scopes Scopes, expiresAt *time.Time) (*APIKey, string, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, "", ErrAPIKeyNameIsRequired
+	}
+	if err := scopes.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	rawPrefix := make([]byte, apiKeyPrefixBytes)
+	if _, err := rand.Read(rawPrefix); err != nil {
+		return nil, "", err
+	}
+	rawSecret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(rawSecret); err != nil {
+		return nil, "", err
+	}
+	prefix := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(rawPrefix))
+	key := APIKeyPrefix + "_" + prefix + "_" + base64.RawURLEncoding.EncodeToString(rawSecret)
+
+	return &APIKey{
+		ID:        entity.NewID(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   HashUserToken(key),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}, key, nil
+}
+
+// ParseAPIKeyPrefix extrai o prefixo de uma chave no formato pk_<prefixo>_<segredo>.
+func ParseAPIKeyPrefix(key string) (string, bool) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != APIKeyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+func (k *APIKey) Matches(key string) bool {
+	return subtle.ConstantTimeCompare([]byte(k.KeyHash), []byte(HashUserToken(key))) == 1
+}
+
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// ShouldTouch evita gravar o último uso a cada requisição.
+func (k *APIKey) ShouldTouch(now time.Time) bool {
+	return k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= apiKeyTouchThreshold
+}
@@ -0,0 +1,75 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	userID := entity.NewID()
+	apiKey, key, err := NewAPIKey(userID, "integration", Scopes{ScopeProductsRead}, nil)
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, userID, apiKey.UserID)
+	assert.True(t, strings.HasPrefix(key, "pk_"+apiKey.Prefix+"_"), "key should start with the prefix")
+	assert.NotContains(t, apiKey.KeyHash, key, "only the hash should be stored")
+	assert.True(t, apiKey.Matches(key))
+	assert.False(t, apiKey.Matches(key+"x"))
+
+	prefix, ok := ParseAPIKeyPrefix(key)
+	assert.True(t, ok)
+	assert.Equal(t, apiKey.Prefix, prefix)
+}
+
+func TestNewAPIKeyInvalid(t *testing.T) {
+	_, _, err := NewAPIKey(entity.NewID(), " ", nil, nil)
+	assert.Equal(t, ErrAPIKeyNameIsRequired, err)
+
+	_, _, err = NewAPIKey(entity.NewID(), "integration", Scopes{"products:delete"}, nil)
+	assert.Equal(t, ErrInvalidScope, err)
+}
+
+func TestParseAPIKeyPrefix(t *testing.T) {
+	for _, key := range []string{"", "pk_", "pk_abc", "xx_abc_def", "pk__def"} {
+		_, ok := ParseAPIKeyPrefix(key)
+		assert.False(t, ok, "key %q should be invalid", key)
+	}
+}
+
+func TestAPIKey_IsExpired(t *testing.T) {
+	now := time.Now()
+	apiKey, _, _ := NewAPIKey(entity.NewID(), "integration", nil, nil)
+	assert.False(t, apiKey.IsExpired(now), "key without expiry should not expire")
+
+	expiresAt := now.Add(time.Hour)
+	apiKey.ExpiresAt = &expiresAt
+	assert.False(t, apiKey.IsExpired(now))
+	assert.True(t, apiKey.IsExpired(expiresAt))
+}
+
+func TestScopes(t *testing.T) {
+	scopes := ParseScopes(" products:read  users:read ")
+	assert.Equal(t, Scopes{ScopeProductsRead, ScopeUsersRead}, scopes)
+	assert.Equal(t, "products:read users:read", scopes.String())
+	assert.True(t, scopes.Contains(ScopeUsersRead))
+	assert.False(t, scopes.Contains(ScopeUsersWrite))
+	assert.Nil(t, scopes.Validate())
+	assert.Equal(t, Scopes{ScopeUsersRead}, scopes.Intersect(Scopes{ScopeUsersRead, ScopeUsersAdmin}))
+}
+
+func TestScopes_Grant(t *testing.T) {
+	allowed := Scopes{ScopeProductsRead, ScopeProductsWrite}
+
+	scopes, err := allowed.Grant(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, allowed, scopes, "empty request should grant every allowed scope")
+
+	scopes, err = allowed.Grant(Scopes{ScopeProductsRead})
+	assert.Nil(t, err)
+	assert.Equal(t, Scopes{ScopeProductsRead}, scopes)
+
+	_, err = allowed.Grant(Scopes{ScopeUsersAdmin})
+	assert.Equal(t, ErrInvalidScope, err, "scopes outside the allowed set should be rejected")
+}
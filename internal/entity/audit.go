@@ -0,0 +1,154 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+)
+
+const (
+	AuditOperationCreate = "create"
+	AuditOperationUpdate = "update"
+	AuditOperationDelete = "delete"
+)
+
+var ErrInvalidAuditOperation = entity.NewError("invalid audit operation")
+
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditChanges guarda a diferença campo a campo, indexada pelo nome JSON do campo.
+type AuditChanges map[string]AuditChange
+
+// AuditRedacted substitui os valores removidos do histórico.
+const AuditRedacted = "[redacted]"
+
+// Redact substitui os valores dos campos informados por AuditRedacted e
+// indica se algum valor foi substituído.
+func (c AuditChanges) Redact(fields ...string) bool {
+	redacted := false
+	for _, field := range fields {
+		change, ok := c[field]
+		if !ok {
+			continue
+		}
+		if change.Before != nil {
+			change.Before = AuditRedacted
+		}
+		if change.After != nil {
+			change.After = AuditRedacted
+		}
+		c[field] = change
+		redacted = true
+	}
+	return redacted
+}
+
+func (c AuditChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(c)
+	return string(b), err
+}
+
+func (c *AuditChanges) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*c = AuditChanges{}
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for audit changes")
+	}
+	return json.Unmarshal(data, c)
+}
+
+type AuditEntry struct {
+	ID         entity.ID    `json:"id"`
+	TenantID   entity.ID    `json:"tenant_id" gorm:"index"`
+	Actor      string       `json:"actor" gorm:"index"`
+	RequestID  string       `json:"request_id"`
+	Operation  string       `json:"operation"`
+	EntityType string       `json:"entity_type" gorm:"index:idx_audit_entity"`
+	EntityID   string       `json:"entity_id" gorm:"index:idx_audit_entity"`
+	Changes    AuditChanges `json:"changes" gorm:"type:text"`
+	CreatedAt  time.Time    `json:"created_at" gorm:"index"`
+}
+
+// NewAuditEntry registra a operação sobre uma entidade. before é nil nas
+// criações e after é nil nas remoções.
+func NewAuditEntry(actor, requestID, operation, entityType, entityID string, before, after interface{}) (*AuditEntry, error) {
+	if operation != AuditOperationCreate && operation != AuditOperationUpdate && operation != AuditOperationDelete {
+		return nil, ErrInvalidAuditOperation
+	}
+
+	changes, err := DiffFields(before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditEntry{
+		ID:         entity.NewID(),
+		Actor:      actor,
+		RequestID:  requestID,
+		Operation:  operation,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    changes,
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+// DiffFields compara as representações JSON de before e after e devolve
+// apenas os campos alterados. Campos com a tag json:"-" nunca aparecem.
+func DiffFields(before, after interface{}) (AuditChanges, error) {
+	beforeFields, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := AuditChanges{}
+	for field, value := range beforeFields {
+		if afterValue, ok := afterFields[field]; !ok || !reflect.DeepEqual(value, afterValue) {
+			changes[field] = AuditChange{Before: value, After: afterFields[field]}
+		}
+	}
+	for field, value := range afterFields {
+		if _, ok := beforeFields[field]; !ok {
+			changes[field] = AuditChange{Before: nil, After: value}
+		}
+	}
+	return changes, nil
+}
+
+func jsonFields(v interface{}) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if v == nil {
+		return fields, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return fields, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
@@ -0,0 +1,65 @@
+package entity
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDiffFields(t *testing.T) {
+	before, _ := NewProduct("product", "description", 10)
+	after := *before
+	after.Price = 12.5
+
+	changes, err := DiffFields(before, &after)
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, 1, len(changes), "only price should change")
+	assert.Equal(t, 10.0, changes["price"].Before, "before should be the old price")
+	assert.Equal(t, 12.5, changes["price"].After, "after should be the new price")
+}
+
+func TestDiffFields_WhenCreatedOrDeleted(t *testing.T) {
+	product, _ := NewProduct("product", "description", 10)
+
+	created, err := DiffFields(nil, product)
+	assert.Nil(t, err, "error should be nil")
+	assert.Nil(t, created["name"].Before, "before should be nil on create")
+	assert.Equal(t, "product", created["name"].After, "after should be the name")
+
+	deleted, err := DiffFields(product, nil)
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, "product", deleted["name"].Before, "before should be the name")
+	assert.Nil(t, deleted["name"].After, "after should be nil on delete")
+}
+
+func TestDiffFields_IgnoresHiddenFields(t *testing.T) {
+	before, _ := NewUser("user", "user@a.com", "secret")
+	after := *before
+	_ = after.SetPassword("changed")
+
+	changes, err := DiffFields(before, &after)
+	assert.Nil(t, err, "error should be nil")
+	assert.Empty(t, changes, "password should never be audited")
+}
+
+func TestNewAuditEntry_WhenInvalidOperation(t *testing.T) {
+	entry, err := NewAuditEntry("actor", "request", "truncate", "products", "1", nil, nil)
+	assert.Nil(t, entry, "entry should be nil")
+	assert.Equal(t, ErrInvalidAuditOperation, err, "error should be the same")
+}
+
+func TestAuditChanges_Redact(t *testing.T) {
+	changes := AuditChanges{
+		"name":  {Before: nil, After: "John"},
+		"email": {Before: "john@a.com", After: "john@b.com"},
+		"role":  {Before: "user", After: "admin"},
+	}
+
+	assert.True(t, changes.Redact("name", "email", "phone"))
+	assert.Nil(t, changes["name"].Before, "missing values should stay missing")
+	assert.Equal(t, AuditRedacted, changes["name"].After)
+	assert.Equal(t, AuditRedacted, changes["email"].Before)
+	assert.Equal(t, AuditRedacted, changes["email"].After)
+	assert.Equal(t, "admin", changes["role"].After, "other fields should be kept")
+
+	assert.False(t, changes.Redact("phone"), "nothing should be redacted")
+}
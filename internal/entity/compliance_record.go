@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"time"
+)
+
+// Pedidos de titulares de dados registrados no log de conformidade.
+const (
+	ComplianceActionExport  = "export"
+	ComplianceActionErasure = "erasure"
+)
+
+var ErrInvalidComplianceAction = entity.NewError("invalid compliance action")
+
+// ComplianceRecord registra o atendimento de um pedido do titular dos dados.
+// Os registros nunca são alterados nem removidos e não guardam dados
+// pessoais, apenas o ID do titular.
+type ComplianceRecord struct {
+	ID        entity.ID `json:"id"`
+	TenantID  entity.ID `json:"tenant_id" gorm:"index"`
+	Action    string    `json:"action" gorm:"index"`
+	SubjectID string    `json:"subject_id" gorm:"index"`
+	Actor     string    `json:"actor"`
+	RequestID string    `json:"request_id"`
+	Details   string    `json:"details,omitempty"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+func NewComplianceRecord(action, subjectID, actor, requestID, details string) (*ComplianceRecord, error) {
+	if action != ComplianceActionExport && action != ComplianceActionErasure {
+		return nil, ErrInvalidComplianceAction
+	}
+	return &ComplianceRecord{
+		ID:        entity.NewID(),
+		Action:    action,
+		SubjectID: subjectID,
+		Actor:     actor,
+		RequestID: requestID,
+		Details:   details,
+		CreatedAt: time.Now(),
+	}, nil
+}
@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewComplianceRecord(t *testing.T) {
+	record, err := NewComplianceRecord(ComplianceActionErasure, "user-1", "admin-1", "req-1", "ticket 42")
+	assert.Nil(t, err, "error should be nil")
+	assert.NotEmpty(t, record.ID)
+	assert.Equal(t, ComplianceActionErasure, record.Action)
+	assert.Equal(t, "user-1", record.SubjectID)
+	assert.False(t, record.CreatedAt.IsZero())
+
+	_, err = NewComplianceRecord("rectification", "user-1", "admin-1", "", "")
+	assert.Equal(t, ErrInvalidComplianceAction, err)
+}
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"time"
+)
+
+type IdempotencyRecord struct {
+	Key         string    `json:"key" gorm:"column:idempotency_key;primaryKey"`
+	Fingerprint string    `json:"fingerprint"`
+	Completed   bool      `json:"completed"`
+	StatusCode  int       `json:"status_code"`
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"body"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at" gorm:"index"`
+}
+
+// NewIdempotencyRecord reserva a chave por lock. Se a requisição não
+// terminar nesse prazo, como quando o processo cai, outra pode assumi-la.
+func NewIdempotencyRecord(key, fingerprint string, lock time.Duration) *IdempotencyRecord {
+	now := time.Now()
+	return &IdempotencyRecord{
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(lock),
+	}
+}
+
+// Complete guarda a resposta, que vale até ttl depois da reserva.
+func (r *IdempotencyRecord) Complete(statusCode int, contentType string, body []byte, ttl time.Duration) {
+	r.ExpiresAt = r.CreatedAt.Add(ttl)
+	r.Completed = true
+	r.StatusCode = statusCode
+	r.ContentType = contentType
+	r.Body = body
+}
+
+func (r *IdempotencyRecord) IsExpired(now time.Time) bool {
+	return !now.Before(r.ExpiresAt)
+}
+
+func (r *IdempotencyRecord) Matches(fingerprint string) bool {
+	return r.Fingerprint == fingerprint
+}
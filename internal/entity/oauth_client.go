@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"strings"
+	"time"
+)
+
+const (
+	oauthClientIDBytes     = 10
+	oauthClientSecretBytes = 32
+)
+
+var ErrOAuthClientNameIsRequired = entity.NewError("name is required")
+
+// OAuthClient é um sistema parceiro autorizado a pedir tokens em /oauth/token.
+// Só o hash do segredo é persistido.
+type OAuthClient struct {
+	ID         entity.ID `json:"id"`
+	TenantID   entity.ID `json:"tenant_id" gorm:"index"`
+	ClientID   string    `json:"client_id" gorm:"uniqueIndex"`
+	Name       string    `json:"name"`
+	SecretHash string    `json:"-"`
+	Scopes     Scopes    `json:"scopes" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// NewOAuthClient gera o client_id e o segredo, que deve ser mostrado uma
+// única vez.
+func NewOAuthClient(name string, scopes Scopes) (*OAuthClient, string, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, "", ErrOAuthClientNameIsRequired
+	}
+	if err := scopes.Validate(); err != nil {
+		return nil, "", err
+	}
+
+	rawID := make([]byte, oauthClientIDBytes)
+	if _, err := rand.Read(rawID); err != nil {
+		return nil, "", err
+	}
+	rawSecret := make([]byte, oauthClientSecretBytes)
+	if _, err := rand.Read(rawSecret); err != nil {
+		return nil, "", err
+	}
+	secret := base64.RawURLEncoding.EncodeToString(rawSecret)
+
+	return &OAuthClient{
+		ID:         entity.NewID(),
+		ClientID:   strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(rawID)),
+		Name:       name,
+		SecretHash: HashUserToken(secret),
+		Scopes:     scopes,
+		CreatedAt:  time.Now(),
+	}, secret, nil
+}
+
+func (c *OAuthClient) CheckSecret(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(c.SecretHash), []byte(HashUserToken(secret))) == 1
+}
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewOAuthClient(t *testing.T) {
+	client, secret, err := NewOAuthClient("partner", Scopes{ScopeProductsRead, ScopeProductsWrite})
+	assert.Nil(t, err, "error should be nil")
+	assert.NotEmpty(t, client.ClientID)
+	assert.NotEmpty(t, secret)
+	assert.NotContains(t, client.SecretHash, secret, "only the hash should be stored")
+	assert.True(t, client.CheckSecret(secret))
+	assert.False(t, client.CheckSecret(secret+"x"))
+}
+
+func TestNewOAuthClientInvalid(t *testing.T) {
+	_, _, err := NewOAuthClient(" ", Scopes{ScopeProductsRead})
+	assert.Equal(t, ErrOAuthClientNameIsRequired, err)
+
+	_, _, err = NewOAuthClient("partner", Scopes{"products:delete"})
+	assert.Equal(t, ErrInvalidScope, err)
+}
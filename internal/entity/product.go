@@ -12,11 +12,13 @@ var (
 )
 
 type Product struct {
-	ID          entity.ID `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID             entity.ID `json:"id"`
+	TenantID       entity.ID `json:"tenant_id" gorm:"index"`
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	Price          float64   `json:"price"`
+	LowestPrice30d float64   `json:"lowest_price_30d" gorm:"-"`
+	CreatedAt      time.Time `json:"created_at"`
 }
 
 func NewProduct(name, description string, price float64) (*Product, error) {
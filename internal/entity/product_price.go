@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"time"
+)
+
+// LowestPriceWindow é o período considerado no cálculo do menor preço recente.
+const LowestPriceWindow = 30 * 24 * time.Hour
+
+type ProductPrice struct {
+	ID            entity.ID `json:"id"`
+	TenantID      entity.ID `json:"tenant_id" gorm:"index"`
+	ProductID     entity.ID `json:"product_id" gorm:"index:idx_product_price"`
+	Price         float64   `json:"price"`
+	EffectiveFrom time.Time `json:"effective_from" gorm:"index:idx_product_price"`
+}
+
+func NewProductPrice(product *Product, effectiveFrom time.Time) *ProductPrice {
+	return &ProductPrice{
+		ID:            entity.NewID(),
+		TenantID:      product.TenantID,
+		ProductID:     product.ID,
+		Price:         product.Price,
+		EffectiveFrom: effectiveFrom,
+	}
+}
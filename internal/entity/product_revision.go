@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"time"
+)
+
+type ProductRevision struct {
+	ID          entity.ID `json:"id"`
+	TenantID    entity.ID `json:"tenant_id" gorm:"index"`
+	ProductID   entity.ID `json:"product_id" gorm:"uniqueIndex:idx_product_revision"`
+	Number      int       `json:"number" gorm:"uniqueIndex:idx_product_revision"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Price       float64   `json:"price"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func NewProductRevision(product *Product, number int, createdBy string) *ProductRevision {
+	return &ProductRevision{
+		ID:          entity.NewID(),
+		TenantID:    product.TenantID,
+		ProductID:   product.ID,
+		Number:      number,
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+		CreatedBy:   createdBy,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// ApplyTo copia o estado da revisão para o produto, sem alterar ID e data de criação.
+func (r *ProductRevision) ApplyTo(product *Product) {
+	product.Name = r.Name
+	product.Description = r.Description
+	product.Price = r.Price
+}
+
+// Snapshot devolve o estado do produto guardado na revisão.
+func (r *ProductRevision) Snapshot() *Product {
+	product := &Product{ID: r.ProductID}
+	r.ApplyTo(product)
+	return product
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestProductRevision_ApplyTo(t *testing.T) {
+	product, _ := NewProduct("product", "description", 10)
+	revision := NewProductRevision(product, 1, "user-1")
+
+	product.Name = "changed"
+	product.Price = 99
+	revision.ApplyTo(product)
+
+	assert.Equal(t, "product", product.Name, "name should be restored")
+	assert.Equal(t, 10.0, product.Price, "price should be restored")
+	assert.Nil(t, ValidateProduct(product), "restored product should be valid")
+}
+
+func TestProductRevision_Snapshot(t *testing.T) {
+	product, _ := NewProduct("product", "description", 10)
+	first := NewProductRevision(product, 1, "")
+	product.Price = 12
+	second := NewProductRevision(product, 2, "")
+
+	changes, err := DiffFields(first.Snapshot(), second.Snapshot())
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, 1, len(changes), "only price should change")
+	assert.Equal(t, 10.0, changes["price"].Before)
+	assert.Equal(t, 12.0, changes["price"].After)
+}
@@ -0,0 +1,69 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+	"time"
+)
+
+const (
+	RecoveryCodeCount  = 10
+	recoveryCodeBytes  = 5
+	recoveryCodeLength = 8
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// RecoveryCode substitui o código TOTP uma única vez quando o usuário perde
+// acesso ao autenticador. Somente o hash é persistido, gerado pelo
+// PasswordHasher: os códigos são curtos e um hash sem sal seria revertido por
+// força bruta a partir de um vazamento do banco.
+type RecoveryCode struct {
+	ID        entity.ID  `json:"id"`
+	UserID    entity.ID  `json:"user_id" gorm:"index"`
+	CodeHash  string     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
+// NewRecoveryCodes gera RecoveryCodeCount códigos e devolve as entidades
+// junto com os valores que devem ser mostrados ao usuário.
+func NewRecoveryCodes(userID entity.ID) ([]*RecoveryCode, []string, error) {
+	now := time.Now()
+	codes := make([]*RecoveryCode, 0, RecoveryCodeCount)
+	plain := make([]string, 0, RecoveryCodeCount)
+	for i := 0; i < RecoveryCodeCount; i++ {
+		raw := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(raw))
+		code = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		hash, err := PasswordHasher.Hash(normalizeRecoveryCode(code))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		codes = append(codes, &RecoveryCode{
+			ID:        entity.NewID(),
+			UserID:    userID,
+			CodeHash:  hash,
+			CreatedAt: now,
+		})
+		plain = append(plain, code)
+	}
+	return codes, plain, nil
+}
+
+// Matches confere o código digitado, ignorando maiúsculas, espaços e hífens.
+// A comparação com o hash é feita em tempo constante pelo PasswordHasher.
+func (c *RecoveryCode) Matches(code string) bool {
+	ok, _, err := PasswordHasher.Verify(c.CodeHash, normalizeRecoveryCode(code))
+	return err == nil && ok
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
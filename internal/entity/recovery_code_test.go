@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	passwordpkg "apis/pkg/password"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, plain, err := NewRecoveryCodes(entity.NewID())
+	assert.Nil(t, err, "error should be nil")
+	assert.Len(t, codes, RecoveryCodeCount)
+	assert.Len(t, plain, RecoveryCodeCount)
+
+	seen := map[string]bool{}
+	for i, code := range plain {
+		assert.Len(t, code, 9, "code should have two groups of four characters")
+		assert.NotContains(t, codes[i].CodeHash, strings.ReplaceAll(code, "-", ""), "only the hash should be stored")
+		assert.True(t, codes[i].Matches(code), "code should match its hash")
+		assert.False(t, seen[code], "codes should be unique")
+		seen[code] = true
+	}
+	assert.False(t, codes[0].Matches(plain[1]), "code should not match another hash")
+}
+
+func TestRecoveryCode_Matches(t *testing.T) {
+	codes, plain, err := NewRecoveryCodes(entity.NewID())
+	assert.Nil(t, err, "error should be nil")
+
+	assert.NotEmpty(t, passwordpkg.Identify(codes[0].CodeHash), "code should be hashed with the password hasher")
+
+	typed := " " + strings.ToUpper(strings.ReplaceAll(plain[0], "-", ""))
+	assert.True(t, codes[0].Matches(typed), "match should ignore case, spaces and hyphens")
+	assert.False(t, codes[0].Matches(""), "empty code should not match")
+}
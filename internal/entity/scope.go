@@ -0,0 +1,95 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"database/sql/driver"
+	"errors"
+	"strings"
+)
+
+const (
+	ScopeProductsRead  = "products:read"
+	ScopeProductsWrite = "products:write"
+	ScopeUsersRead     = "users:read"
+	ScopeUsersWrite    = "users:write"
+	ScopeUsersAdmin    = "users:admin"
+)
+
+var ErrInvalidScope = entity.NewError("invalid scope")
+
+// AllScopes lista os escopos conhecidos pela API.
+var AllScopes = Scopes{ScopeProductsRead, ScopeProductsWrite, ScopeUsersRead, ScopeUsersWrite, ScopeUsersAdmin}
+
+// Scopes é persistido e transmitido como texto separado por espaços, como a
+// claim scope do OAuth2.
+type Scopes []string
+
+func ParseScopes(value string) Scopes {
+	return Scopes(strings.Fields(value))
+}
+
+func (s Scopes) String() string {
+	return strings.Join(s, " ")
+}
+
+func (s Scopes) Contains(scope string) bool {
+	for _, item := range s {
+		if item == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Intersect devolve os escopos presentes em s e em other, na ordem de s.
+func (s Scopes) Intersect(other Scopes) Scopes {
+	result := Scopes{}
+	for _, scope := range s {
+		if other.Contains(scope) {
+			result = append(result, scope)
+		}
+	}
+	return result
+}
+
+// Grant devolve os escopos pedidos quando todos estão entre os permitidos em
+// s. Sem escopo pedido, concede todos os permitidos.
+func (s Scopes) Grant(requested Scopes) (Scopes, error) {
+	if len(requested) == 0 {
+		return s, nil
+	}
+	for _, scope := range requested {
+		if !s.Contains(scope) {
+			return nil, ErrInvalidScope
+		}
+	}
+	return requested, nil
+}
+
+// Validate rejeita escopos desconhecidos.
+func (s Scopes) Validate() error {
+	for _, scope := range s {
+		if !AllScopes.Contains(scope) {
+			return ErrInvalidScope
+		}
+	}
+	return nil
+}
+
+func (s Scopes) Value() (driver.Value, error) {
+	return s.String(), nil
+}
+
+func (s *Scopes) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*s = Scopes{}
+	case []byte:
+		*s = ParseScopes(string(v))
+	case string:
+		*s = ParseScopes(v)
+	default:
+		return errors.New("unsupported type for scopes")
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"time"
+)
+
+const (
+	// SessionIDClaim liga o token de acesso à sessão que o emitiu.
+	SessionIDClaim = "sid"
+
+	maxSessionUserAgentLength = 255
+	sessionTouchThreshold     = time.Minute
+)
+
+// Session registra cada login do usuário para que ele possa ver onde está
+// conectado e encerrar sessões remotamente.
+type Session struct {
+	ID         entity.ID  `json:"id"`
+	UserID     entity.ID  `json:"user_id" gorm:"index"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+func NewSession(userID entity.ID, userAgent, ip string, ttl time.Duration) *Session {
+	if len(userAgent) > maxSessionUserAgentLength {
+		userAgent = userAgent[:maxSessionUserAgentLength]
+	}
+	now := time.Now()
+	return &Session{
+		ID:         entity.NewID(),
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IP:         ip,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(ttl),
+	}
+}
+
+// IsActive indica se a sessão não foi encerrada nem expirou.
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// ShouldTouch evita gravar o último acesso a cada requisição.
+func (s *Session) ShouldTouch(now time.Time) bool {
+	return now.Sub(s.LastSeenAt) >= sessionTouchThreshold
+}
@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewSession(t *testing.T) {
+	userID := entity.NewID()
+	session := NewSession(userID, strings.Repeat("a", 300), "10.0.0.1", time.Hour)
+	assert.Equal(t, userID, session.UserID)
+	assert.Len(t, session.UserAgent, maxSessionUserAgentLength, "user agent should be truncated")
+	assert.Equal(t, "10.0.0.1", session.IP)
+	assert.Equal(t, session.CreatedAt, session.LastSeenAt)
+
+	now := time.Now()
+	assert.True(t, session.IsActive(now))
+	assert.False(t, session.IsActive(now.Add(2*time.Hour)), "expired session should not be active")
+	assert.False(t, session.ShouldTouch(now))
+	assert.True(t, session.ShouldTouch(now.Add(2*time.Minute)))
+
+	session.RevokedAt = &now
+	assert.False(t, session.IsActive(now), "revoked session should not be active")
+}
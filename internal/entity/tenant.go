@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TenantIDClaim identifica no JWT o tenant do token.
+const TenantIDClaim = "tenant_id"
+
+// DefaultTenantSlug identifica o tenant criado na inicialização, que recebe os
+// registros anteriores ao suporte a tenants. Seus administradores gerenciam
+// os demais tenants.
+const DefaultTenantSlug = "default"
+
+var (
+	ErrTenantNameIsRequired = entity.NewError("name is required")
+	ErrInvalidTenantSlug    = entity.NewError("slug must contain only lowercase letters, digits and hyphens")
+)
+
+var tenantSlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// Tenant é uma loja atendida pela mesma instalação. Usuários e produtos
+// pertencem a um único tenant e nunca são vistos pelos demais.
+type Tenant struct {
+	ID        entity.ID `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug" gorm:"uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewTenant(name, slug string) (*Tenant, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrTenantNameIsRequired
+	}
+	if !tenantSlugPattern.MatchString(slug) {
+		return nil, ErrInvalidTenantSlug
+	}
+	return &Tenant{
+		ID:        entity.NewID(),
+		Name:      name,
+		Slug:      slug,
+		CreatedAt: time.Now(),
+	}, nil
+}
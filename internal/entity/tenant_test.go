@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewTenant(t *testing.T) {
+	tenant, err := NewTenant(" Acme Store ", "acme-store")
+	assert.Nil(t, err, "error should be nil")
+	assert.NotEmpty(t, tenant.ID)
+	assert.Equal(t, "Acme Store", tenant.Name)
+	assert.Equal(t, "acme-store", tenant.Slug)
+}
+
+func TestNewTenantInvalid(t *testing.T) {
+	_, err := NewTenant(" ", "acme")
+	assert.Equal(t, ErrTenantNameIsRequired, err)
+
+	for _, slug := range []string{"", "Acme", "acme store", "-acme", "acme-", "acme_store"} {
+		_, err = NewTenant("Acme", slug)
+		assert.Equal(t, ErrInvalidTenantSlug, err, "slug %q should be rejected", slug)
+	}
+}
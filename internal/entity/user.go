@@ -2,28 +2,220 @@ package entity
 
 import (
 	"apis/pkg/entity"
+	passwordpkg "apis/pkg/password"
+	"apis/pkg/totp"
 	"golang.org/x/crypto/bcrypt"
+	"time"
 )
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Situações da conta. Somente contas ativas fazem login e usam seus tokens.
+const (
+	UserStatusActive      = "active"
+	UserStatusSuspended   = "suspended"
+	UserStatusDeactivated = "deactivated"
+)
+
+var ErrInvalidUserStatus = entity.NewError("invalid user status")
+
+// ErrUserErased indica que a conta foi apagada e não pode mais mudar de situação.
+var ErrUserErased = entity.NewError("user has been erased")
+
+// ErasedUserName substitui o nome dos usuários cujos dados foram apagados.
+const ErasedUserName = "Erased user"
+
+// UserPersonalFields lista os campos JSON do usuário com dados pessoais,
+// removidos também do histórico de auditoria no apagamento.
+var UserPersonalFields = []string{"name", "email"}
+
 type User struct {
 	ID       entity.ID `json:"id"`
+	TenantID entity.ID `json:"tenant_id" gorm:"uniqueIndex:idx_user_email"`
 	Name     string    `json:"name"`
-	Email    string    `json:"email"`
+	Email    string    `json:"email" gorm:"uniqueIndex:idx_user_email"`
 	Password string    `json:"-"`
+	Role     string    `json:"role" gorm:"default:user"`
+
+	EmailVerifiedAt     *time.Time `json:"email_verified_at,omitempty"`
+	FailedLoginAttempts int        `json:"-"`
+	LockedUntil         *time.Time `json:"locked_until,omitempty"`
+
+	TOTPEnabled  bool   `json:"totp_enabled"`
+	TOTPSecret   string `json:"-"`
+	TOTPLastStep int64  `json:"-"`
+
+	Status          string     `json:"status" gorm:"default:active"`
+	StatusReason    string     `json:"status_reason,omitempty"`
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`
+
+	ErasedAt *time.Time `json:"erased_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
 }
 
+// TOTPSkew é o número de intervalos vizinhos aceitos na validação do código.
+const TOTPSkew = 1
+
+// PasswordHasher gera e confere os hashes de senha. É substituído na
+// inicialização pelo algoritmo configurado.
+var PasswordHasher = passwordpkg.NewService(passwordpkg.NewBcrypt(bcrypt.DefaultCost))
+
 func (u *User) SetPassword(password string) error {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hash, err := PasswordHasher.Hash(password)
 	if err != nil {
 		return err
 	}
-	u.Password = string(hash)
+	u.Password = hash
 	return nil
 }
 
 func (u *User) CheckPassword(password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
-	return err == nil
+	ok, _, _ := PasswordHasher.Verify(u.Password, password)
+	return ok
+}
+
+// VerifyPassword confere a senha e, quando o hash usa um algoritmo ou
+// parâmetros antigos, refaz o hash com os atuais. rehashed indica que o
+// usuário precisa ser gravado.
+func (u *User) VerifyPassword(password string) (valid bool, rehashed bool) {
+	ok, rehash, _ := PasswordHasher.Verify(u.Password, password)
+	if !ok {
+		return false, false
+	}
+	if rehash && u.SetPassword(password) == nil {
+		return true, true
+	}
+	return true, false
+}
+
+// CheckDummyPassword consome o mesmo tempo de CheckPassword quando o usuário
+// não existe, para que e-mails desconhecidos não sejam distinguíveis de
+// senhas erradas.
+func CheckDummyPassword(password string) {
+	PasswordHasher.VerifyDummy(password)
+}
+
+// IsLocked indica se a conta está bloqueada por excesso de tentativas de login.
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// RegisterLoginFailure contabiliza uma senha errada e bloqueia a conta por
+// lockout ao atingir maxAttempts. Devolve true quando a conta foi bloqueada.
+func (u *User) RegisterLoginFailure(now time.Time, maxAttempts int, lockout time.Duration) bool {
+	if u.LockedUntil != nil && !u.IsLocked(now) {
+		u.Unlock()
+	}
+	u.FailedLoginAttempts++
+	if maxAttempts > 0 && u.FailedLoginAttempts >= maxAttempts {
+		lockedUntil := now.Add(lockout)
+		u.LockedUntil = &lockedUntil
+		return true
+	}
+	return false
+}
+
+// AllowedScopes lista os escopos que o usuário pode pedir em seus tokens.
+// Somente administradores recebem users:admin.
+func (u *User) AllowedScopes() Scopes {
+	if u.Role == RoleAdmin {
+		return AllScopes
+	}
+	return Scopes{ScopeProductsRead, ScopeProductsWrite, ScopeUsersRead, ScopeUsersWrite}
+}
+
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+func (u *User) VerifyEmail(now time.Time) {
+	if u.EmailVerifiedAt == nil {
+		u.EmailVerifiedAt = &now
+	}
+}
+
+// EnrollTOTP guarda um novo segredo, que só passa a ser exigido no login
+// após ConfirmTOTP.
+func (u *User) EnrollTOTP(secret string) {
+	u.TOTPSecret = secret
+	u.TOTPEnabled = false
+	u.TOTPLastStep = 0
+}
+
+// ConfirmTOTP ativa a autenticação em dois fatores quando o código é válido
+// para o segredo cadastrado.
+func (u *User) ConfirmTOTP(code string, now time.Time) bool {
+	if u.TOTPSecret == "" || !u.ValidateTOTP(code, now) {
+		return false
+	}
+	u.TOTPEnabled = true
+	return true
+}
+
+// ValidateTOTP confere o código e rejeita códigos de intervalos já usados.
+func (u *User) ValidateTOTP(code string, now time.Time) bool {
+	step, ok := totp.Validate(u.TOTPSecret, code, now, TOTPSkew)
+	if !ok || step <= u.TOTPLastStep {
+		return false
+	}
+	u.TOTPLastStep = step
+	return true
+}
+
+// Unlock zera as tentativas de login e remove o bloqueio da conta.
+func (u *User) Unlock() {
+	u.FailedLoginAttempts = 0
+	u.LockedUntil = nil
+}
+
+// IsActive indica se a conta pode fazer login. Contas anteriores ao campo
+// Status são ativas.
+func (u *User) IsActive() bool {
+	return u.Status == "" || u.Status == UserStatusActive
+}
+
+// ChangeStatus muda a situação da conta registrando o motivo e o momento.
+// O apagamento é definitivo: contas apagadas não mudam mais de situação.
+func (u *User) ChangeStatus(status, reason string, now time.Time) error {
+	if u.IsErased() {
+		return ErrUserErased
+	}
+	switch status {
+	case UserStatusActive, UserStatusSuspended, UserStatusDeactivated:
+	default:
+		return ErrInvalidUserStatus
+	}
+	u.Status = status
+	u.StatusReason = reason
+	u.StatusChangedAt = &now
+	return nil
+}
+
+// Anonymize apaga os dados pessoais do usuário mantendo o ID, para que os
+// registros criados por ele continuem válidos. A conta fica desativada e sem
+// senha, sem possibilidade de login.
+func (u *User) Anonymize(now time.Time) {
+	u.Name = ErasedUserName
+	u.Email = "erased-" + u.ID.String() + "@erased.invalid"
+	u.Password = ""
+	u.EmailVerifiedAt = nil
+	u.FailedLoginAttempts = 0
+	u.LockedUntil = nil
+	u.TOTPEnabled = false
+	u.TOTPSecret = ""
+	u.TOTPLastStep = 0
+	u.Status = UserStatusDeactivated
+	u.StatusReason = ""
+	u.StatusChangedAt = &now
+	u.ErasedAt = &now
+}
+
+func (u *User) IsErased() bool {
+	return u.ErasedAt != nil
 }
 
 func (u *User) SetName(name string) *User {
@@ -34,9 +226,11 @@ func (u *User) SetName(name string) *User {
 	return u
 }
 
+// SetEmail troca o e-mail do usuário, que volta a ficar não verificado.
 func (u *User) SetEmail(email string) *User {
-	if email != "" {
+	if email != "" && email != u.Email {
 		u.Email = email
+		u.EmailVerifiedAt = nil
 	}
 
 	return u
@@ -44,9 +238,11 @@ func (u *User) SetEmail(email string) *User {
 
 func NewUser(name, email, password string) (*User, error) {
 	user := &User{
-		ID:    entity.NewID(),
-		Name:  name,
-		Email: email,
+		ID:     entity.NewID(),
+		Name:   name,
+		Email:  email,
+		Role:   RoleUser,
+		Status: UserStatusActive,
 	}
 	err := user.SetPassword(password)
 
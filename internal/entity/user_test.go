@@ -1,8 +1,13 @@
 package entity
 
 import (
+	passwordpkg "apis/pkg/password"
+	"apis/pkg/totp"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewUser(t *testing.T) {
@@ -56,3 +61,139 @@ func TestUser_ValidatePassword(t *testing.T) {
 		assert.NotEqual(t, tt.args.password, user.Password, "password should not be the same")
 	}
 }
+
+func TestUser_VerifyPasswordRehash(t *testing.T) {
+	previous := PasswordHasher
+	defer func() { PasswordHasher = previous }()
+
+	bcryptHasher := passwordpkg.NewBcrypt(bcrypt.MinCost)
+	PasswordHasher = passwordpkg.NewService(bcryptHasher)
+	user, _ := NewUser("test", "test@a.com", "test")
+	assert.True(t, strings.HasPrefix(user.Password, "$2a$"), "password should use bcrypt")
+
+	PasswordHasher = passwordpkg.NewService(passwordpkg.NewArgon2id(1024, 1, 1), bcryptHasher)
+	valid, rehashed := user.VerifyPassword("wrong")
+	assert.False(t, valid)
+	assert.False(t, rehashed)
+
+	valid, rehashed = user.VerifyPassword("test")
+	assert.True(t, valid)
+	assert.True(t, rehashed, "outdated hash should be rehashed")
+	assert.True(t, strings.HasPrefix(user.Password, "$argon2id$"), "password should use argon2id")
+
+	valid, rehashed = user.VerifyPassword("test")
+	assert.True(t, valid)
+	assert.False(t, rehashed, "current hash should be kept")
+}
+
+func TestUser_RegisterLoginFailure(t *testing.T) {
+	user, _ := NewUser("test", "test@a.com", "test")
+	now := time.Now()
+
+	assert.False(t, user.RegisterLoginFailure(now, 3, time.Minute))
+	assert.False(t, user.RegisterLoginFailure(now, 3, time.Minute))
+	assert.False(t, user.IsLocked(now), "user should not be locked before max attempts")
+
+	assert.True(t, user.RegisterLoginFailure(now, 3, time.Minute), "user should be locked at max attempts")
+	assert.True(t, user.IsLocked(now.Add(30*time.Second)), "user should be locked until lockout expires")
+	assert.False(t, user.IsLocked(now.Add(time.Minute)), "lockout should expire")
+
+	assert.False(t, user.RegisterLoginFailure(now.Add(2*time.Minute), 3, time.Minute), "expired lockout should reset attempts")
+	assert.Equal(t, 1, user.FailedLoginAttempts)
+	assert.Nil(t, user.LockedUntil)
+}
+
+func TestUser_Unlock(t *testing.T) {
+	user, _ := NewUser("test", "test@a.com", "test")
+	now := time.Now()
+	user.RegisterLoginFailure(now, 1, time.Hour)
+	assert.True(t, user.IsLocked(now))
+
+	user.Unlock()
+	assert.False(t, user.IsLocked(now), "user should be unlocked")
+	assert.Equal(t, 0, user.FailedLoginAttempts)
+}
+
+func TestUser_AllowedScopes(t *testing.T) {
+	user, _ := NewUser("test", "test@a.com", "test")
+	assert.False(t, user.AllowedScopes().Contains(ScopeUsersAdmin), "regular users should not get users:admin")
+	assert.True(t, user.AllowedScopes().Contains(ScopeProductsWrite))
+
+	user.Role = RoleAdmin
+	assert.Equal(t, AllScopes, user.AllowedScopes())
+}
+
+func TestUser_VerifyEmail(t *testing.T) {
+	user, _ := NewUser("test", "test@a.com", "test")
+	assert.False(t, user.IsEmailVerified(), "new user should not be verified")
+
+	now := time.Now()
+	user.VerifyEmail(now)
+	assert.True(t, user.IsEmailVerified(), "user should be verified")
+
+	user.VerifyEmail(now.Add(time.Hour))
+	assert.Equal(t, now, *user.EmailVerifiedAt, "verification time should be kept")
+
+	user.SetEmail("test@a.com")
+	assert.True(t, user.IsEmailVerified(), "same email should keep verification")
+	user.SetEmail("other@a.com")
+	assert.False(t, user.IsEmailVerified(), "new email should require verification")
+}
+
+func TestUser_TOTP(t *testing.T) {
+	user, _ := NewUser("test", "test@a.com", "test")
+	secret, _ := totp.GenerateSecret()
+	now := time.Now()
+	code, _ := totp.Code(secret, totp.Step(now))
+
+	assert.False(t, user.ConfirmTOTP(code, now), "confirmation without enrollment should fail")
+
+	user.EnrollTOTP(secret)
+	assert.False(t, user.TOTPEnabled, "enrollment should not enable TOTP")
+	assert.False(t, user.ConfirmTOTP("000000", now), "invalid code should not confirm")
+	assert.True(t, user.ConfirmTOTP(code, now), "valid code should confirm")
+	assert.True(t, user.TOTPEnabled)
+
+	assert.False(t, user.ValidateTOTP(code, now), "code should not be reused")
+	next, _ := totp.Code(secret, totp.Step(now)+1)
+	assert.True(t, user.ValidateTOTP(next, now.Add(totp.Period)), "next code should be valid")
+}
+
+func TestUser_ChangeStatus(t *testing.T) {
+	user, _ := NewUser("test", "test@a.com", "test")
+	assert.True(t, user.IsActive(), "new user should be active")
+	assert.True(t, (&User{}).IsActive(), "user without status should be active")
+
+	now := time.Now()
+	assert.Nil(t, user.ChangeStatus(UserStatusSuspended, "chargeback", now))
+	assert.False(t, user.IsActive(), "suspended user should not be active")
+	assert.Equal(t, "chargeback", user.StatusReason)
+	assert.Equal(t, now, *user.StatusChangedAt)
+
+	assert.Equal(t, ErrInvalidUserStatus, user.ChangeStatus("banned", "", now))
+	assert.Equal(t, UserStatusSuspended, user.Status, "invalid status should not be applied")
+
+	assert.Nil(t, user.ChangeStatus(UserStatusActive, "", now))
+	assert.True(t, user.IsActive(), "reactivated user should be active")
+
+	user.Anonymize(now)
+	assert.Equal(t, ErrUserErased, user.ChangeStatus(UserStatusActive, "", now))
+	assert.False(t, user.IsActive(), "erased user should not be reactivated")
+}
+
+func TestUser_Anonymize(t *testing.T) {
+	user, _ := NewUser("John", "john@a.com", "secret")
+	user.VerifyEmail(time.Now())
+	user.EnrollTOTP("JBSWY3DPEHPK3PXP")
+	id := user.ID
+
+	now := time.Now()
+	user.Anonymize(now)
+	assert.Equal(t, id, user.ID, "ID should be kept")
+	assert.Equal(t, ErasedUserName, user.Name)
+	assert.NotContains(t, user.Email, "john")
+	assert.Empty(t, user.TOTPSecret)
+	assert.False(t, user.CheckPassword("secret"), "erased user should not log in")
+	assert.False(t, user.IsActive(), "erased user should be deactivated")
+	assert.True(t, user.IsErased())
+}
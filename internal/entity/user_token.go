@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposeMFAChallenge      = "mfa_challenge"
+
+	userTokenBytes = 32
+)
+
+// UserToken é um token de uso único enviado ao usuário por e-mail. Somente o
+// hash SHA-256 é persistido; o valor em texto só existe na mensagem enviada.
+type UserToken struct {
+	ID        entity.ID  `json:"id"`
+	UserID    entity.ID  `json:"user_id" gorm:"index"`
+	Purpose   string     `json:"purpose" gorm:"index"`
+	TokenHash string     `json:"-" gorm:"uniqueIndex"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+}
+
+// NewUserToken gera um token aleatório e devolve a entidade junto com o valor
+// que deve ser enviado ao usuário.
+func NewUserToken(userID entity.ID, purpose string, ttl time.Duration) (*UserToken, string, error) {
+	raw := make([]byte, userTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	now := time.Now()
+	return &UserToken{
+		ID:        entity.NewID(),
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: HashUserToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}, token, nil
+}
+
+func HashUserToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (t *UserToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+func (t *UserToken) IsUsed() bool {
+	return t.UsedAt != nil
+}
+
+// IsValid indica se o token ainda pode ser consumido.
+func (t *UserToken) IsValid(now time.Time) bool {
+	return !t.IsUsed() && !t.IsExpired(now)
+}
@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"apis/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNewUserToken(t *testing.T) {
+	userID := entity.NewID()
+	token, plain, err := NewUserToken(userID, TokenPurposePasswordReset, time.Hour)
+	assert.Nil(t, err, "error should be nil")
+	assert.NotEmpty(t, plain, "plain token should not be empty")
+	assert.Equal(t, userID, token.UserID)
+	assert.Equal(t, TokenPurposePasswordReset, token.Purpose)
+	assert.Equal(t, HashUserToken(plain), token.TokenHash, "only the hash should be stored")
+	assert.NotEqual(t, plain, token.TokenHash)
+
+	_, other, _ := NewUserToken(userID, TokenPurposePasswordReset, time.Hour)
+	assert.NotEqual(t, plain, other, "tokens should be random")
+}
+
+func TestUserToken_IsValid(t *testing.T) {
+	token, _, _ := NewUserToken(entity.NewID(), TokenPurposePasswordReset, time.Hour)
+	now := time.Now()
+
+	assert.True(t, token.IsValid(now))
+	assert.False(t, token.IsValid(now.Add(time.Hour)), "expired token should be invalid")
+
+	token.UsedAt = &now
+	assert.False(t, token.IsValid(now), "used token should be invalid")
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+type APIKey struct {
+	DB *gorm.DB
+}
+
+func NewAPIKey(db *gorm.DB) *APIKey {
+	return &APIKey{DB: db}
+}
+
+func (ak *APIKey) WithContext(ctx context.Context) APIKeyInterface {
+	return NewAPIKey(ak.DB.WithContext(ctx))
+}
+
+func (ak *APIKey) Create(apiKey *entity.APIKey) error {
+	return ak.DB.Create(apiKey).Error
+}
+
+func (ak *APIKey) FindByUser(userID string) ([]*entity.APIKey, error) {
+	var apiKeys []*entity.APIKey
+	if err := ak.DB.Where("user_id = ?", userID).Order("created_at desc").Find(&apiKeys).Error; err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+func (ak *APIKey) FindByID(userID, id string) (*entity.APIKey, error) {
+	var apiKey entity.APIKey
+	if err := ak.DB.Where("user_id = ? AND id = ?", userID, id).First(&apiKey).Error; err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+func (ak *APIKey) FindByPrefix(prefix string) (*entity.APIKey, error) {
+	var apiKey entity.APIKey
+	if err := ak.DB.Where("prefix = ?", prefix).First(&apiKey).Error; err != nil {
+		return nil, err
+	}
+	return &apiKey, nil
+}
+
+func (ak *APIKey) Update(apiKey *entity.APIKey) error {
+	return ak.DB.Save(apiKey).Error
+}
+
+// Touch registra o último uso da chave sem passar pela auditoria.
+func (ak *APIKey) Touch(apiKey *entity.APIKey, now time.Time) error {
+	err := ak.DB.Model(&entity.APIKey{}).Where("id = ?", apiKey.ID).UpdateColumn("last_used_at", now).Error
+	if err != nil {
+		return err
+	}
+	apiKey.LastUsedAt = &now
+	return nil
+}
+
+func (ak *APIKey) Delete(userID, id string) error {
+	result := ak.DB.Where("user_id = ? AND id = ?", userID, id).Delete(&entity.APIKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestAPIKey_FindByPrefix(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.APIKey{})
+	defer cleanup()
+
+	apiKeyDB := NewAPIKey(db)
+	apiKey, key, _ := entity.NewAPIKey(entitypkg.NewID(), "integration", entity.Scopes{entity.ScopeProductsRead}, nil)
+	assert.Nil(t, apiKeyDB.Create(apiKey), "error should be nil")
+
+	prefix, _ := entity.ParseAPIKeyPrefix(key)
+	found, err := apiKeyDB.FindByPrefix(prefix)
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, apiKey.ID, found.ID)
+	assert.Equal(t, entity.Scopes{entity.ScopeProductsRead}, found.Scopes, "scopes should be persisted")
+	assert.True(t, found.Matches(key))
+}
+
+func TestAPIKey_FindByUser(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.APIKey{})
+	defer cleanup()
+
+	apiKeyDB := NewAPIKey(db)
+	userID := entitypkg.NewID()
+	for _, name := range []string{"first", "second"} {
+		apiKey, _, _ := entity.NewAPIKey(userID, name, nil, nil)
+		assert.Nil(t, apiKeyDB.Create(apiKey))
+	}
+	other, _, _ := entity.NewAPIKey(entitypkg.NewID(), "other", nil, nil)
+	assert.Nil(t, apiKeyDB.Create(other))
+
+	apiKeys, err := apiKeyDB.FindByUser(userID.String())
+	assert.Nil(t, err, "error should be nil")
+	assert.Len(t, apiKeys, 2, "only the user's keys should be listed")
+
+	_, err = apiKeyDB.FindByID(userID.String(), other.ID.String())
+	assert.NotNil(t, err, "keys of other users should not be found")
+}
+
+func TestAPIKey_TouchAndDelete(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.APIKey{})
+	defer cleanup()
+
+	apiKeyDB := NewAPIKey(db)
+	userID := entitypkg.NewID()
+	apiKey, _, _ := entity.NewAPIKey(userID, "integration", nil, nil)
+	assert.Nil(t, apiKeyDB.Create(apiKey))
+
+	assert.Nil(t, apiKeyDB.Touch(apiKey, time.Now()), "error should be nil")
+	found, _ := apiKeyDB.FindByID(userID.String(), apiKey.ID.String())
+	assert.NotNil(t, found.LastUsedAt, "last used should be persisted")
+
+	assert.NotNil(t, apiKeyDB.Delete(entitypkg.NewID().String(), apiKey.ID.String()), "other users should not delete the key")
+	assert.Nil(t, apiKeyDB.Delete(userID.String(), apiKey.ID.String()))
+	_, err := apiKeyDB.FindByID(userID.String(), apiKey.ID.String())
+	assert.NotNil(t, err, "key should be deleted")
+}
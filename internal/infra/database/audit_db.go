@@ -0,0 +1,232 @@
+package database
+
+import (
+	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"reflect"
+	"time"
+)
+
+const auditBeforeKey = "audit:before"
+
+type auditContextKey struct{}
+
+// AuditMetadata identifica quem executou a operação auditada.
+type AuditMetadata struct {
+	Actor     string
+	RequestID string
+}
+
+func ContextWithAudit(ctx context.Context, metadata AuditMetadata) context.Context {
+	return context.WithValue(ctx, auditContextKey{}, metadata)
+}
+
+func AuditFromContext(ctx context.Context) AuditMetadata {
+	if ctx == nil {
+		return AuditMetadata{}
+	}
+	metadata, _ := ctx.Value(auditContextKey{}).(AuditMetadata)
+	return metadata
+}
+
+type AuditFilter struct {
+	Actor      string
+	EntityType string
+	EntityID   string
+	From       time.Time
+	To         time.Time
+	Page       int
+	Limit      int
+}
+
+// Audit restringe as consultas ao tenant do contexto.
+type Audit struct {
+	DB       *gorm.DB
+	TenantID entitypkg.ID
+}
+
+func NewAudit(db *gorm.DB) *Audit {
+	return &Audit{DB: db}
+}
+
+func (a *Audit) WithContext(ctx context.Context) AuditInterface {
+	return &Audit{DB: a.DB.WithContext(ctx), TenantID: tenantOf(ctx, a.TenantID)}
+}
+
+func (a *Audit) Create(entry *entity.AuditEntry) error {
+	if err := requireTenant(a.TenantID); err != nil {
+		return err
+	}
+	entry.TenantID = a.TenantID
+	return a.DB.Create(entry).Error
+}
+
+func (a *Audit) FindByEntity(entityType, entityID string) ([]*entity.AuditEntry, error) {
+	var entries []*entity.AuditEntry
+	err := tenantScoped(a.DB, a.TenantID).
+		Where("entity_type = ? AND entity_id = ?", entityType, entityID).
+		Order("created_at asc").
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (a *Audit) FindAll(filter AuditFilter) ([]*entity.AuditEntry, error) {
+	var entries []*entity.AuditEntry
+
+	query := tenantScoped(a.DB, a.TenantID).Order("created_at desc")
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at <= ?", filter.To)
+	}
+	if filter.Page != 0 && filter.Limit != 0 {
+		query = query.Offset((filter.Page - 1) * filter.Limit).Limit(filter.Limit)
+	}
+
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// RegisterAuditCallbacks grava uma entrada de auditoria para cada criação,
+// atualização e remoção nas tabelas informadas. A entrada é gravada na mesma
+// transação da operação, usando o AuditMetadata do contexto da consulta.
+func RegisterAuditCallbacks(db *gorm.DB, tables ...string) error {
+	audited := map[string]bool{}
+	for _, table := range tables {
+		audited[table] = true
+	}
+
+	isAudited := func(tx *gorm.DB) bool {
+		return tx.Error == nil && tx.Statement.Schema != nil && audited[tx.Statement.Schema.Table]
+	}
+
+	if err := db.Callback().Create().After("gorm:create").Register("audit:after_create", func(tx *gorm.DB) {
+		if !isAudited(tx) {
+			return
+		}
+		forEachAuditedRecord(tx, func(record reflect.Value) {
+			recordAudit(tx, entity.AuditOperationCreate, record, nil, record.Interface())
+		})
+	}); err != nil {
+		return err
+	}
+
+	if err := db.Callback().Update().Before("gorm:update").Register("audit:before_update", func(tx *gorm.DB) {
+		if isAudited(tx) {
+			loadAuditBefore(tx)
+		}
+	}); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register("audit:after_update", func(tx *gorm.DB) {
+		if !isAudited(tx) || tx.RowsAffected == 0 {
+			return
+		}
+		if before, ok := tx.InstanceGet(auditBeforeKey); ok {
+			record := reflect.Indirect(tx.Statement.ReflectValue)
+			recordAudit(tx, entity.AuditOperationUpdate, record, before, record.Interface())
+		}
+	}); err != nil {
+		return err
+	}
+
+	if err := db.Callback().Delete().Before("gorm:delete").Register("audit:before_delete", func(tx *gorm.DB) {
+		if isAudited(tx) {
+			loadAuditBefore(tx)
+		}
+	}); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:delete").Register("audit:after_delete", func(tx *gorm.DB) {
+		if !isAudited(tx) || tx.RowsAffected == 0 {
+			return
+		}
+		if before, ok := tx.InstanceGet(auditBeforeKey); ok {
+			recordAudit(tx, entity.AuditOperationDelete, reflect.Indirect(reflect.ValueOf(before)), before, nil)
+		}
+	})
+}
+
+func forEachAuditedRecord(tx *gorm.DB, fn func(record reflect.Value)) {
+	value := reflect.Indirect(tx.Statement.ReflectValue)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			fn(reflect.Indirect(value.Index(i)))
+		}
+	case reflect.Struct:
+		fn(value)
+	}
+}
+
+// loadAuditBefore carrega o estado atual do registro antes da alteração.
+// Operações em lote sem chave primária não são auditadas.
+func loadAuditBefore(tx *gorm.DB) {
+	value := reflect.Indirect(tx.Statement.ReflectValue)
+	field := tx.Statement.Schema.PrioritizedPrimaryField
+	if value.Kind() != reflect.Struct || field == nil {
+		return
+	}
+	id, isZero := field.ValueOf(tx.Statement.Context, value)
+	if isZero {
+		return
+	}
+
+	before := reflect.New(tx.Statement.Schema.ModelType).Interface()
+	err := tx.Session(&gorm.Session{NewDB: true}).
+		Where(fmt.Sprintf("%s = ?", field.DBName), id).
+		Take(before).Error
+	if err == nil {
+		tx.InstanceSet(auditBeforeKey, before)
+	}
+}
+
+func recordAudit(tx *gorm.DB, operation string, record reflect.Value, before, after interface{}) {
+	field := tx.Statement.Schema.PrioritizedPrimaryField
+	if field == nil {
+		return
+	}
+	id, _ := field.ValueOf(tx.Statement.Context, record)
+
+	metadata := AuditFromContext(tx.Statement.Context)
+	entry, err := entity.NewAuditEntry(metadata.Actor, metadata.RequestID, operation, tx.Statement.Schema.Table, fmt.Sprint(id), before, after)
+	if err != nil {
+		_ = tx.AddError(err)
+		return
+	}
+	// A entrada pertence ao tenant do registro auditado, mesmo quando a
+	// operação não tem tenant no contexto.
+	entry.TenantID = TenantFromContext(tx.Statement.Context)
+	if field := tx.Statement.Schema.LookUpField("tenant_id"); field != nil {
+		if value, isZero := field.ValueOf(tx.Statement.Context, record); !isZero {
+			if tenantID, ok := value.(entitypkg.ID); ok {
+				entry.TenantID = tenantID
+			}
+		}
+	}
+	if operation == entity.AuditOperationUpdate && len(entry.Changes) == 0 {
+		return
+	}
+
+	if err := tx.Session(&gorm.Session{NewDB: true}).Create(entry).Error; err != nil {
+		_ = tx.AddError(err)
+	}
+}
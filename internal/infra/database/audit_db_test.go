@@ -0,0 +1,110 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func initAuditDBTest(t *testing.T) (AuditInterface, ProductInterface, func()) {
+	db, cleanup := initDBTest(t, &entity.Product{}, &entity.ProductRevision{}, &entity.ProductPrice{})
+	migrateTable(db, t, &entity.AuditEntry{})
+	if err := RegisterAuditCallbacks(db, "products"); err != nil {
+		t.Fatal(err)
+	}
+	return NewAudit(db).WithContext(tenantContext(context.Background())), NewProduct(db).WithContext(tenantContext(context.Background())), cleanup
+}
+
+func TestAudit_RecordsProductChanges(t *testing.T) {
+	auditDB, productDB, cleanup := initAuditDBTest(t)
+	defer cleanup()
+
+	ctx := ContextWithAudit(tenantContext(context.Background()), AuditMetadata{Actor: "user-1", RequestID: "req-1"})
+	productDb := productDB.WithContext(ctx)
+
+	product, _ := entity.NewProduct("product test", "product description", 10)
+	err := productDb.Create(product)
+	assert.Nil(t, err, "error should be nil")
+
+	product.Price = 15
+	err = productDb.Update(product)
+	assert.Nil(t, err, "error should be nil")
+
+	err = productDb.Delete(product.ID.String())
+	assert.Nil(t, err, "error should be nil")
+
+	entries, err := auditDB.FindByEntity("products", product.ID.String())
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, 3, len(entries), "every change should be audited")
+
+	assert.Equal(t, entity.AuditOperationCreate, entries[0].Operation)
+	assert.Equal(t, entity.AuditOperationUpdate, entries[1].Operation)
+	assert.Equal(t, entity.AuditOperationDelete, entries[2].Operation)
+	for _, entry := range entries {
+		assert.Equal(t, "user-1", entry.Actor, "actor should come from context")
+		assert.Equal(t, "req-1", entry.RequestID, "request ID should come from context")
+	}
+
+	assert.Equal(t, 1, len(entries[1].Changes), "only the price should change")
+	assert.Equal(t, 10.0, entries[1].Changes["price"].Before)
+	assert.Equal(t, 15.0, entries[1].Changes["price"].After)
+}
+
+func TestAudit_RecordsBatchInserts(t *testing.T) {
+	auditDB, productDB, cleanup := initAuditDBTest(t)
+	defer cleanup()
+
+	p1, _ := entity.NewProduct("product 1", "description 1", 10)
+	p2, _ := entity.NewProduct("product 2", "description 2", 20)
+	err := productDB.CreateInBatches([]*entity.Product{p1, p2}, 1)
+	assert.Nil(t, err, "error should be nil")
+
+	entries, err := auditDB.FindAll(AuditFilter{EntityType: "products"})
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, 2, len(entries), "each product should be audited")
+}
+
+func TestAudit_RollsBackWithTransaction(t *testing.T) {
+	auditDB, productDB, cleanup := initAuditDBTest(t)
+	defer cleanup()
+
+	product, _ := entity.NewProduct("product", "description", 10)
+	missing, _ := entity.NewProduct("missing", "description", 10)
+	_ = productDB.Transaction(func(tx ProductInterface) error {
+		if err := tx.Create(product); err != nil {
+			return err
+		}
+		return tx.Delete(missing.ID.String())
+	})
+
+	entries, err := auditDB.FindByEntity("products", product.ID.String())
+	assert.Nil(t, err, "error should be nil")
+	assert.Empty(t, entries, "audit should be rolled back with the change")
+}
+
+func TestAudit_FindAll(t *testing.T) {
+	auditDB, _, cleanup := initAuditDBTest(t)
+	defer cleanup()
+
+	old, _ := entity.NewAuditEntry("user-1", "", entity.AuditOperationCreate, "products", "1", nil, map[string]int{"a": 1})
+	old.CreatedAt = time.Now().Add(-48 * time.Hour)
+	recent, _ := entity.NewAuditEntry("user-2", "", entity.AuditOperationCreate, "users", "2", nil, map[string]int{"a": 1})
+	assert.Nil(t, auditDB.Create(old))
+	assert.Nil(t, auditDB.Create(recent))
+
+	entries, err := auditDB.FindAll(AuditFilter{Actor: "user-1"})
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, 1, len(entries), "should filter by actor")
+
+	entries, err = auditDB.FindAll(AuditFilter{EntityType: "users"})
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, 1, len(entries), "should filter by entity")
+	assert.Equal(t, "2", entries[0].EntityID)
+
+	entries, err = auditDB.FindAll(AuditFilter{From: time.Now().Add(-24 * time.Hour)})
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, 1, len(entries), "should filter by time range")
+	assert.Equal(t, "user-2", entries[0].Actor)
+}
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"runtime"
+	"strings"
+)
+
+const packagePath = "apis/internal/infra/database."
+
+// RepositoryMethod procura na pilha de chamadas o primeiro método deste pacote,
+// como "(*Product).FindById". É usado pelos plugins do GORM para identificar
+// qual método do repositório originou a consulta.
+func RepositoryMethod() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if strings.HasPrefix(frame.Function, packagePath) {
+			name := strings.TrimPrefix(frame.Function, packagePath)
+			// Ignora closures como "(*Product).Update.func1".
+			if i := strings.Index(name, ".func"); i > 0 {
+				name = name[:i]
+			}
+			return name
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+)
+
+var ErrComplianceRecordImmutable = errors.New("compliance records cannot be changed")
+
+// Compliance restringe a listagem ao tenant do contexto.
+type Compliance struct {
+	DB       *gorm.DB
+	TenantID entitypkg.ID
+}
+
+func NewCompliance(db *gorm.DB) *Compliance {
+	return &Compliance{DB: db}
+}
+
+func (c *Compliance) WithContext(ctx context.Context) ComplianceInterface {
+	return &Compliance{DB: c.DB.WithContext(ctx), TenantID: tenantOf(ctx, c.TenantID)}
+}
+
+func (c *Compliance) Create(record *entity.ComplianceRecord) error {
+	if err := requireTenant(c.TenantID); err != nil {
+		return err
+	}
+	record.TenantID = c.TenantID
+	return c.DB.Create(record).Error
+}
+
+// FindAll lista os registros do mais antigo para o mais recente, apenas do
+// titular informado quando subjectID não é vazio.
+func (c *Compliance) FindAll(subjectID string) ([]*entity.ComplianceRecord, error) {
+	var records []*entity.ComplianceRecord
+	query := tenantScoped(c.DB, c.TenantID).Order("created_at asc")
+	if subjectID != "" {
+		query = query.Where("subject_id = ?", subjectID)
+	}
+	if err := query.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// RegisterComplianceCallbacks impede que os registros de conformidade sejam
+// alterados ou removidos pela aplicação.
+func RegisterComplianceCallbacks(db *gorm.DB) error {
+	reject := func(tx *gorm.DB) {
+		if tx.Statement.Schema != nil && tx.Statement.Schema.Table == "compliance_records" {
+			_ = tx.AddError(ErrComplianceRecordImmutable)
+		}
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("compliance:reject_update", reject); err != nil {
+		return err
+	}
+	return db.Callback().Delete().Before("gorm:delete").Register("compliance:reject_delete", reject)
+}
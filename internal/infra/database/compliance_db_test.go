@@ -0,0 +1,46 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCompliance_FindAll(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.ComplianceRecord{})
+	defer cleanup()
+
+	complianceDB := NewCompliance(db).WithContext(tenantContext(context.Background()))
+	for _, subject := range []string{"user-1", "user-2", "user-1"} {
+		record, err := entity.NewComplianceRecord(entity.ComplianceActionExport, subject, "admin-1", "", "")
+		assert.Nil(t, err, "error should be nil")
+		assert.Nil(t, complianceDB.Create(record), "error should be nil")
+	}
+
+	records, err := complianceDB.FindAll("")
+	assert.Nil(t, err, "error should be nil")
+	assert.Len(t, records, 3)
+
+	records, err = complianceDB.FindAll("user-1")
+	assert.Nil(t, err, "error should be nil")
+	assert.Len(t, records, 2, "only records of the subject should be listed")
+}
+
+func TestCompliance_RecordsAreImmutable(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.ComplianceRecord{})
+	defer cleanup()
+	assert.Nil(t, RegisterComplianceCallbacks(db))
+
+	record, _ := entity.NewComplianceRecord(entity.ComplianceActionErasure, "user-1", "admin-1", "", "ticket 42")
+	assert.Nil(t, NewCompliance(db).WithContext(tenantContext(context.Background())).Create(record), "records should be created")
+
+	record.Details = "changed"
+	assert.ErrorIs(t, db.Save(record).Error, ErrComplianceRecordImmutable, "records should not be updated")
+	assert.ErrorIs(t, db.Model(&entity.ComplianceRecord{}).Where("1 = 1").Update("details", "changed").Error, ErrComplianceRecordImmutable)
+	assert.ErrorIs(t, db.Delete(record).Error, ErrComplianceRecordImmutable, "records should not be deleted")
+
+	var stored entity.ComplianceRecord
+	assert.Nil(t, db.First(&stored, "id = ?", record.ID).Error)
+	assert.Equal(t, "ticket 42", stored.Details)
+}
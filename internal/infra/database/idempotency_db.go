@@ -0,0 +1,55 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type Idempotency struct {
+	DB *gorm.DB
+}
+
+func NewIdempotency(db *gorm.DB) *Idempotency {
+	return &Idempotency{DB: db}
+}
+
+// Reserve grava o registro somente se a chave ainda não existir. O retorno
+// false indica que outra requisição já reservou a mesma chave.
+func (i *Idempotency) Reserve(record *entity.IdempotencyRecord) (bool, error) {
+	result := i.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (i *Idempotency) FindByKey(key string) (*entity.IdempotencyRecord, error) {
+	var record entity.IdempotencyRecord
+	if err := i.DB.Where("idempotency_key = ?", key).First(&record).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (i *Idempotency) Complete(record *entity.IdempotencyRecord) error {
+	return i.DB.Save(record).Error
+}
+
+func (i *Idempotency) Delete(key string) error {
+	return i.DB.Where("idempotency_key = ?", key).Delete(&entity.IdempotencyRecord{}).Error
+}
+
+// DeleteIfExpired apaga o registro da chave somente se ele já expirou. Assim,
+// duas requisições que encontram o mesmo registro expirado não apagam a nova
+// reserva uma da outra. O retorno false indica que nada foi apagado.
+func (i *Idempotency) DeleteIfExpired(key string, now time.Time) (bool, error) {
+	result := i.DB.Where("idempotency_key = ? AND expires_at <= ?", key, now).Delete(&entity.IdempotencyRecord{})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (i *Idempotency) DeleteExpired(now time.Time) (int64, error) {
+	result := i.DB.Where("expires_at <= ?", now).Delete(&entity.IdempotencyRecord{})
+	return result.RowsAffected, result.Error
+}
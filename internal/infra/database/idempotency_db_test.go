@@ -0,0 +1,87 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestIdempotency_Reserve(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.IdempotencyRecord{})
+	defer cleanup()
+
+	idempotencyDB := NewIdempotency(db)
+
+	reserved, err := idempotencyDB.Reserve(entity.NewIdempotencyRecord("key", "fingerprint", time.Hour))
+	assert.Nil(t, err, "error should be nil")
+	assert.True(t, reserved, "first reservation should succeed")
+
+	reserved, err = idempotencyDB.Reserve(entity.NewIdempotencyRecord("key", "other", time.Hour))
+	assert.Nil(t, err, "error should be nil")
+	assert.False(t, reserved, "second reservation should fail")
+
+	record, err := idempotencyDB.FindByKey("key")
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, "fingerprint", record.Fingerprint, "first reservation should be kept")
+	assert.False(t, record.Completed, "record should not be completed")
+}
+
+func TestIdempotency_Complete(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.IdempotencyRecord{})
+	defer cleanup()
+
+	idempotencyDB := NewIdempotency(db)
+	record := entity.NewIdempotencyRecord("key", "fingerprint", time.Hour)
+	_, err := idempotencyDB.Reserve(record)
+	assert.Nil(t, err, "error should be nil")
+
+	record.Complete(201, "application/json", []byte(`{"id":"1"}`), time.Hour)
+	err = idempotencyDB.Complete(record)
+	assert.Nil(t, err, "error should be nil")
+
+	stored, err := idempotencyDB.FindByKey("key")
+	assert.Nil(t, err, "error should be nil")
+	assert.True(t, stored.Completed, "record should be completed")
+	assert.Equal(t, 201, stored.StatusCode, "status should be the same")
+	assert.Equal(t, `{"id":"1"}`, string(stored.Body), "body should be the same")
+}
+
+func TestIdempotency_DeleteExpired(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.IdempotencyRecord{})
+	defer cleanup()
+
+	idempotencyDB := NewIdempotency(db)
+	_, _ = idempotencyDB.Reserve(entity.NewIdempotencyRecord("expired", "fingerprint", -time.Minute))
+	_, _ = idempotencyDB.Reserve(entity.NewIdempotencyRecord("valid", "fingerprint", time.Hour))
+
+	deleted, err := idempotencyDB.DeleteExpired(time.Now())
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, int64(1), deleted, "only the expired record should be deleted")
+
+	_, err = idempotencyDB.FindByKey("expired")
+	assert.NotNil(t, err, "expired record should not exist")
+	_, err = idempotencyDB.FindByKey("valid")
+	assert.Nil(t, err, "valid record should exist")
+}
+
+func TestIdempotency_DeleteIfExpired(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.IdempotencyRecord{})
+	defer cleanup()
+
+	idempotencyDB := NewIdempotency(db)
+	_, _ = idempotencyDB.Reserve(entity.NewIdempotencyRecord("expired", "fingerprint", -time.Minute))
+	_, _ = idempotencyDB.Reserve(entity.NewIdempotencyRecord("valid", "fingerprint", time.Hour))
+
+	deleted, err := idempotencyDB.DeleteIfExpired("valid", time.Now())
+	assert.Nil(t, err, "error should be nil")
+	assert.False(t, deleted, "valid record should not be deleted")
+	_, err = idempotencyDB.FindByKey("valid")
+	assert.Nil(t, err, "valid record should exist")
+
+	deleted, err = idempotencyDB.DeleteIfExpired("expired", time.Now())
+	assert.Nil(t, err, "error should be nil")
+	assert.True(t, deleted, "expired record should be deleted")
+	_, err = idempotencyDB.FindByKey("expired")
+	assert.NotNil(t, err, "expired record should not exist")
+}
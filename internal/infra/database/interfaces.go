@@ -1,20 +1,119 @@
 package database
 
-import "apis/internal/entity"
+import (
+	"apis/internal/entity"
+	"context"
+	"time"
+)
 
 type UserInterface interface {
-	FindAll() ([]*entity.User, error)
+	WithContext(ctx context.Context) UserInterface
+	FindAll(filter UserFilter) ([]*entity.User, int64, error)
 	FindById(id string) (*entity.User, error)
+	FindByIdAcrossTenants(id string) (*entity.User, error)
 	FindByEmail(email string) (*entity.User, error)
 	Create(user *entity.User) error
 	Update(user *entity.User) error
+	RegisterLoginFailure(id string, now time.Time, maxAttempts int, lockout time.Duration) (bool, error)
 	Delete(id string) error
+	Erase(user *entity.User, record *entity.ComplianceRecord) error
 }
 
 type ProductInterface interface {
+	WithContext(ctx context.Context) ProductInterface
 	Create(product *entity.Product) error
+	CreateInBatches(products []*entity.Product, batchSize int) error
 	FindAll(page, limit int, sort string) ([]*entity.Product, error)
 	FindById(id string) (*entity.Product, error)
 	Update(product *entity.Product) error
 	Delete(id string) error
+	Transaction(fn func(tx ProductInterface) error) error
+}
+
+type IdempotencyInterface interface {
+	Reserve(record *entity.IdempotencyRecord) (bool, error)
+	FindByKey(key string) (*entity.IdempotencyRecord, error)
+	Complete(record *entity.IdempotencyRecord) error
+	Delete(key string) error
+	DeleteIfExpired(key string, now time.Time) (bool, error)
+	DeleteExpired(now time.Time) (int64, error)
+}
+
+type AuditInterface interface {
+	WithContext(ctx context.Context) AuditInterface
+	Create(entry *entity.AuditEntry) error
+	FindByEntity(entityType, entityID string) ([]*entity.AuditEntry, error)
+	FindAll(filter AuditFilter) ([]*entity.AuditEntry, error)
+}
+
+type ProductRevisionInterface interface {
+	WithContext(ctx context.Context) ProductRevisionInterface
+	FindByProduct(productID string) ([]*entity.ProductRevision, error)
+	FindByNumber(productID string, number int) (*entity.ProductRevision, error)
+}
+
+type ProductPriceInterface interface {
+	WithContext(ctx context.Context) ProductPriceInterface
+	FindByProduct(productID string) ([]*entity.ProductPrice, error)
+	FindLowestSince(productIDs []string, since time.Time) (map[string]float64, error)
+}
+
+type UserTokenInterface interface {
+	WithContext(ctx context.Context) UserTokenInterface
+	Create(token *entity.UserToken) error
+	FindByToken(purpose, token string) (*entity.UserToken, error)
+	Use(token *entity.UserToken, now time.Time) error
+	DeleteByUser(userID, purpose string) error
+}
+
+type RecoveryCodeInterface interface {
+	WithContext(ctx context.Context) RecoveryCodeInterface
+	Replace(userID string, codes []*entity.RecoveryCode) error
+	Use(userID, code string, now time.Time) error
+	CountUnused(userID string) (int64, error)
+}
+
+type APIKeyInterface interface {
+	WithContext(ctx context.Context) APIKeyInterface
+	Create(apiKey *entity.APIKey) error
+	FindByUser(userID string) ([]*entity.APIKey, error)
+	FindByID(userID, id string) (*entity.APIKey, error)
+	FindByPrefix(prefix string) (*entity.APIKey, error)
+	Update(apiKey *entity.APIKey) error
+	Touch(apiKey *entity.APIKey, now time.Time) error
+	Delete(userID, id string) error
+}
+
+type OAuthClientInterface interface {
+	WithContext(ctx context.Context) OAuthClientInterface
+	Create(client *entity.OAuthClient) error
+	FindAll() ([]*entity.OAuthClient, error)
+	FindByClientID(clientID string) (*entity.OAuthClient, error)
+	FindByClientIDAcrossTenants(clientID string) (*entity.OAuthClient, error)
+	Delete(id string) error
+}
+
+type SessionInterface interface {
+	WithContext(ctx context.Context) SessionInterface
+	Create(session *entity.Session) error
+	FindByID(id string) (*entity.Session, error)
+	FindByUser(userID string) ([]*entity.Session, error)
+	FindActiveByUser(userID string, now time.Time) ([]*entity.Session, error)
+	Touch(session *entity.Session, now time.Time) error
+	Revoke(userID, id string, now time.Time) error
+	RevokeAll(userID string, now time.Time, exceptID string) (int64, error)
+}
+
+type ComplianceInterface interface {
+	WithContext(ctx context.Context) ComplianceInterface
+	Create(record *entity.ComplianceRecord) error
+	FindAll(subjectID string) ([]*entity.ComplianceRecord, error)
+}
+
+type TenantInterface interface {
+	WithContext(ctx context.Context) TenantInterface
+	Create(tenant *entity.Tenant) error
+	FindAll() ([]*entity.Tenant, error)
+	FindByID(id string) (*entity.Tenant, error)
+	FindBySlug(slug string) (*entity.Tenant, error)
 }
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"apis/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const DefaultSlowQueryThreshold = 200 * time.Millisecond
+
+// GormLogger envia os logs do GORM para o logger da requisição presente no
+// contexto da consulta, de forma que cada SQL carregue o ID da requisição.
+type GormLogger struct {
+	SlowThreshold time.Duration
+	level         gormlogger.LogLevel
+}
+
+func NewGormLogger(slowThreshold time.Duration) *GormLogger {
+	return &GormLogger{SlowThreshold: slowThreshold, level: gormlogger.Info}
+}
+
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	copied := *l
+	copied.level = level
+	return &copied
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Info {
+		logger.FromContext(ctx).Info(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		logger.FromContext(ctx).Warn(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Error {
+		logger.FromContext(ctx).Error(fmt.Sprintf(msg, args...))
+	}
+}
+
+// ParamsFilter descarta os valores da consulta, para que os logs tragam o SQL
+// com os placeholders e nunca senhas, tokens ou dados pessoais.
+func (l *GormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	log := logger.FromContext(ctx)
+	fields := func() []zap.Field {
+		sql, rows := fc()
+		return []zap.Field{zap.String("sql", sql), zap.Int64("rows", rows), zap.Duration("elapsed", elapsed)}
+	}
+
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		log.Error("query failed", append(fields(), zap.Error(err))...)
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold && l.level >= gormlogger.Warn:
+		log.Warn("slow query", fields()...)
+	case l.level >= gormlogger.Info && log.Core().Enabled(zap.DebugLevel):
+		log.Debug("query", fields()...)
+	}
+}
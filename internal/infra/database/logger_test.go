@@ -0,0 +1,38 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"apis/pkg/logger"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGormLogger_OmitsQueryValues(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: NewGormLogger(DefaultSlowQueryThreshold)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&entity.User{}); err != nil {
+		t.Fatal(err)
+	}
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	ctx := logger.WithContext(tenantContext(context.Background()), zap.New(core))
+	_, err = NewUser(db).WithContext(ctx).FindByEmail("secret@example.com")
+	assert.NotNil(t, err)
+
+	entries := logs.FilterMessage("query").All()
+	assert.NotEmpty(t, entries, "query should be logged")
+	for _, entry := range entries {
+		sql := entry.ContextMap()["sql"].(string)
+		assert.Contains(t, sql, "?")
+		assert.NotContains(t, sql, "secret@example.com", "query values should not be logged")
+	}
+}
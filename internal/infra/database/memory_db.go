@@ -1,6 +1,8 @@
 package database
 
 import (
+	entitypkg "apis/pkg/entity"
+	"context"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"testing"
@@ -34,8 +36,17 @@ func migrateTable(db *gorm.DB, t *testing.T, model interface{}) {
 	}
 }
 
-func initDBTest(t *testing.T, model interface{}) (*gorm.DB, func()) {
+func initDBTest(t *testing.T, models ...interface{}) (*gorm.DB, func()) {
 	db, cleanup := prepareDB(t)
-	migrateTable(db, t, model)
+	for _, model := range models {
+		migrateTable(db, t, model)
+	}
 	return db, cleanup
 }
+
+// testTenantID é o tenant dos repositórios usados nos testes.
+var testTenantID = entitypkg.NewID()
+
+func tenantContext(ctx context.Context) context.Context {
+	return ContextWithTenant(ctx, testTenantID)
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"context"
+	"gorm.io/gorm"
+)
+
+// OAuthClient restringe as operações ao tenant do contexto. Em /oauth/token
+// não há tenant: ele é definido pelo próprio cliente, buscado com
+// FindByClientIDAcrossTenants.
+type OAuthClient struct {
+	DB       *gorm.DB
+	TenantID entitypkg.ID
+}
+
+func NewOAuthClient(db *gorm.DB) *OAuthClient {
+	return &OAuthClient{DB: db}
+}
+
+func (oc *OAuthClient) WithContext(ctx context.Context) OAuthClientInterface {
+	return &OAuthClient{DB: oc.DB.WithContext(ctx), TenantID: tenantOf(ctx, oc.TenantID)}
+}
+
+func (oc *OAuthClient) scoped() *gorm.DB {
+	return tenantScoped(oc.DB, oc.TenantID)
+}
+
+func (oc *OAuthClient) Create(client *entity.OAuthClient) error {
+	if err := requireTenant(oc.TenantID); err != nil {
+		return err
+	}
+	client.TenantID = oc.TenantID
+	return oc.DB.Create(client).Error
+}
+
+func (oc *OAuthClient) FindAll() ([]*entity.OAuthClient, error) {
+	var clients []*entity.OAuthClient
+	if err := oc.scoped().Order("created_at desc").Find(&clients).Error; err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+func (oc *OAuthClient) FindByClientID(clientID string) (*entity.OAuthClient, error) {
+	var client entity.OAuthClient
+	if err := oc.scoped().Where("client_id = ?", clientID).First(&client).Error; err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// FindByClientIDAcrossTenants busca o cliente em qualquer tenant, para a
+// autenticação do cliente em /oauth/token.
+func (oc *OAuthClient) FindByClientIDAcrossTenants(clientID string) (*entity.OAuthClient, error) {
+	var client entity.OAuthClient
+	if err := oc.DB.Where("client_id = ?", clientID).First(&client).Error; err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (oc *OAuthClient) Delete(id string) error {
+	result := oc.scoped().Where("id = ?", id).Delete(&entity.OAuthClient{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
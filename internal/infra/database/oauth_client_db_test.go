@@ -0,0 +1,49 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestOAuthClient_FindByClientID(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.OAuthClient{})
+	defer cleanup()
+
+	clientDB := NewOAuthClient(db).WithContext(tenantContext(context.Background()))
+	client, secret, _ := entity.NewOAuthClient("partner", entity.Scopes{entity.ScopeProductsRead})
+	assert.Nil(t, clientDB.Create(client), "error should be nil")
+
+	found, err := clientDB.FindByClientID(client.ClientID)
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, client.ID, found.ID)
+	assert.Equal(t, entity.Scopes{entity.ScopeProductsRead}, found.Scopes, "scopes should be persisted")
+	assert.True(t, found.CheckSecret(secret))
+
+	_, err = clientDB.FindByClientID("unknown")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestOAuthClient_FindAllAndDelete(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.OAuthClient{})
+	defer cleanup()
+
+	clientDB := NewOAuthClient(db).WithContext(tenantContext(context.Background()))
+	for _, name := range []string{"first", "second"} {
+		client, _, _ := entity.NewOAuthClient(name, nil)
+		assert.Nil(t, clientDB.Create(client))
+	}
+
+	clients, err := clientDB.FindAll()
+	assert.Nil(t, err)
+	assert.Len(t, clients, 2)
+
+	assert.Nil(t, clientDB.Delete(clients[0].ID.String()))
+	assert.ErrorIs(t, clientDB.Delete(clients[0].ID.String()), gorm.ErrRecordNotFound, "deleting twice should report not found")
+
+	clients, err = clientDB.FindAll()
+	assert.Nil(t, err)
+	assert.Len(t, clients, 1)
+}
@@ -2,24 +2,77 @@ package database
 
 import (
 	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"context"
 	"gorm.io/gorm"
 )
 
+// Product restringe todas as operações ao TenantID, que vem do contexto
+// recebido em WithContext.
 type Product struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	TenantID entitypkg.ID
 }
 
 func NewProduct(db *gorm.DB) *Product {
 	return &Product{DB: db}
 }
 
+func (p *Product) WithContext(ctx context.Context) ProductInterface {
+	return &Product{DB: p.DB.WithContext(ctx), TenantID: tenantOf(ctx, p.TenantID)}
+}
+
+func (p *Product) scoped() *gorm.DB {
+	return tenantScoped(p.DB, p.TenantID)
+}
+
+// assignTenant grava os produtos no tenant do repositório.
+func (p *Product) assignTenant(products ...*entity.Product) error {
+	if err := requireTenant(p.TenantID); err != nil {
+		return err
+	}
+	for _, product := range products {
+		product.TenantID = p.TenantID
+	}
+	return nil
+}
+
 func (p *Product) Create(product *entity.Product) error {
-	return p.DB.Create(product).Error
+	if err := p.assignTenant(product); err != nil {
+		return err
+	}
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		if err := createProductPrices(tx, product); err != nil {
+			return err
+		}
+		return createProductRevisions(tx, product)
+	})
+}
+
+func (p *Product) CreateInBatches(products []*entity.Product, batchSize int) error {
+	if len(products) == 0 {
+		return nil
+	}
+	if err := p.assignTenant(products...); err != nil {
+		return err
+	}
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(products, batchSize).Error; err != nil {
+			return err
+		}
+		if err := createProductPrices(tx, products...); err != nil {
+			return err
+		}
+		return createProductRevisions(tx, products...)
+	})
 }
 
 func (p *Product) FindById(id string) (*entity.Product, error) {
 	var product entity.Product
-	if err := p.DB.Where("id = ?", id).First(&product).Error; err != nil {
+	if err := p.scoped().Where("id = ?", id).First(&product).Error; err != nil {
 		return nil, err
 	}
 	return &product, nil
@@ -33,13 +86,13 @@ func (p *Product) FindAll(page, limit int, sort string) ([]*entity.Product, erro
 	}
 
 	if page != 0 && limit != 0 {
-		if err := p.DB.Order("created_at " + sort).Offset((page - 1) * limit).Limit(limit).Find(&products).Error; err != nil {
+		if err := p.scoped().Order("created_at " + sort).Offset((page - 1) * limit).Limit(limit).Find(&products).Error; err != nil {
 			return nil, err
 		}
 		return products, nil
 	}
 
-	if err := p.DB.Order("created_at " + sort).Find(&products).Error; err != nil {
+	if err := p.scoped().Order("created_at " + sort).Find(&products).Error; err != nil {
 		return nil, err
 	}
 
@@ -47,11 +100,26 @@ func (p *Product) FindAll(page, limit int, sort string) ([]*entity.Product, erro
 }
 
 func (p *Product) Update(product *entity.Product) error {
-	_, err := p.FindById(product.ID.String())
+	current, err := p.FindById(product.ID.String())
 	if err != nil {
 		return err
 	}
-	return p.DB.Save(product).Error
+	// O produto nunca muda de tenant.
+	product.TenantID = current.TenantID
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(product).Error; err != nil {
+			return err
+		}
+		if current.Price != product.Price {
+			if err := createInitialProductPrice(tx, current); err != nil {
+				return err
+			}
+			if err := createProductPrices(tx, product); err != nil {
+				return err
+			}
+		}
+		return createProductRevisions(tx, product)
+	})
 }
 
 func (p *Product) Delete(id string) error {
@@ -59,5 +127,11 @@ func (p *Product) Delete(id string) error {
 	if err != nil {
 		return err
 	}
-	return p.DB.Delete(product).Error
+	return p.scoped().Delete(product).Error
+}
+
+func (p *Product) Transaction(fn func(tx ProductInterface) error) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		return fn(&Product{DB: tx, TenantID: p.TenantID})
+	})
 }
@@ -2,6 +2,8 @@ package database
 
 import (
 	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"context"
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"math/rand"
@@ -9,14 +11,14 @@ import (
 )
 
 func TestProduct_Create(t *testing.T) {
-	db, cleanup := initDBTest(t, &entity.Product{})
+	db, cleanup := initDBTest(t, &entity.Product{}, &entity.ProductRevision{}, &entity.ProductPrice{})
 	defer cleanup()
 
 	product, err := entity.NewProduct("product test", "product description", 21.9)
 	if err != nil {
 		t.Error(err)
 	}
-	productDb := NewProduct(db)
+	productDb := NewProduct(db).WithContext(tenantContext(context.Background()))
 	err = productDb.Create(product)
 	assert.Nil(t, err, "error should be nil")
 	assert.NotNil(t, product, "product should not be nil")
@@ -33,7 +35,7 @@ func TestProduct_Create(t *testing.T) {
 }
 
 func TestProduct_FindAll(t *testing.T) {
-	db, cleanup := initDBTest(t, &entity.Product{})
+	db, cleanup := initDBTest(t, &entity.Product{}, &entity.ProductRevision{}, &entity.ProductPrice{})
 	defer cleanup()
 
 	for i := 0; i < 106; i++ {
@@ -41,27 +43,27 @@ func TestProduct_FindAll(t *testing.T) {
 		if err != nil {
 			t.Error(err)
 		}
-		productDb := NewProduct(db)
+		productDb := NewProduct(db).WithContext(tenantContext(context.Background()))
 		err = productDb.Create(product)
 		assert.Nil(t, err, "error should be nil")
 		assert.NotNil(t, product, "product should not be nil")
 	}
 
-	products, err := NewProduct(db).FindAll(1, 10, "asc")
+	products, err := NewProduct(db).WithContext(tenantContext(context.Background())).FindAll(1, 10, "asc")
 	assert.Nil(t, err, "error should be nil")
 	assert.NotNil(t, products, "products should not be nil")
 	assert.Equal(t, 10, len(products), "length should be 10")
 	assert.Equal(t, "product test 0", products[0].Name, "name should be the same")
 	assert.Equal(t, "product test 9", products[9].Name, "name should be the same")
 
-	products, err = NewProduct(db).FindAll(2, 10, "asc")
+	products, err = NewProduct(db).WithContext(tenantContext(context.Background())).FindAll(2, 10, "asc")
 	assert.Nil(t, err, "error should be nil")
 	assert.NotNil(t, products, "products should not be nil")
 	assert.Equal(t, 10, len(products), "length should be 10")
 	assert.Equal(t, "product test 10", products[0].Name, "name should be the same")
 	assert.Equal(t, "product test 19", products[9].Name, "name should be the same")
 
-	products, err = NewProduct(db).FindAll(11, 10, "asc")
+	products, err = NewProduct(db).WithContext(tenantContext(context.Background())).FindAll(11, 10, "asc")
 	assert.Nil(t, err, "error should be nil")
 	assert.NotNil(t, products, "products should not be nil")
 	assert.Equal(t, 6, len(products), "length should be 10")
@@ -71,14 +73,14 @@ func TestProduct_FindAll(t *testing.T) {
 }
 
 func TestProduct_FindById(t *testing.T) {
-	db, cleanup := initDBTest(t, &entity.Product{})
+	db, cleanup := initDBTest(t, &entity.Product{}, &entity.ProductRevision{}, &entity.ProductPrice{})
 	defer cleanup()
 
 	product, err := entity.NewProduct("product test", "product description", 21.9)
 	if err != nil {
 		t.Error(err)
 	}
-	productDb := NewProduct(db)
+	productDb := NewProduct(db).WithContext(tenantContext(context.Background()))
 	err = productDb.Create(product)
 	assert.Nil(t, err, "error should be nil")
 	assert.NotNil(t, product, "product should not be nil")
@@ -94,14 +96,14 @@ func TestProduct_FindById(t *testing.T) {
 }
 
 func TestProduct_Update(t *testing.T) {
-	db, cleanup := initDBTest(t, &entity.Product{})
+	db, cleanup := initDBTest(t, &entity.Product{}, &entity.ProductRevision{}, &entity.ProductPrice{})
 	defer cleanup()
 
 	product, err := entity.NewProduct("product test", "product description", 21.9)
 	if err != nil {
 		t.Error(err)
 	}
-	productDb := NewProduct(db)
+	productDb := NewProduct(db).WithContext(tenantContext(context.Background()))
 	err = productDb.Create(product)
 	assert.Nil(t, err, "error should be nil")
 	assert.NotNil(t, product, "product should not be nil")
@@ -123,14 +125,14 @@ func TestProduct_Update(t *testing.T) {
 }
 
 func TestProduct_Delete(t *testing.T) {
-	db, cleanup := initDBTest(t, &entity.Product{})
+	db, cleanup := initDBTest(t, &entity.Product{}, &entity.ProductRevision{}, &entity.ProductPrice{})
 	defer cleanup()
 
 	product, err := entity.NewProduct("product test", "product description", 21.9)
 	if err != nil {
 		t.Error(err)
 	}
-	productDb := NewProduct(db)
+	productDb := NewProduct(db).WithContext(tenantContext(context.Background()))
 	err = productDb.Create(product)
 	assert.Nil(t, err, "error should be nil")
 	assert.NotNil(t, product, "product should not be nil")
@@ -142,3 +144,90 @@ func TestProduct_Delete(t *testing.T) {
 	_, err = productDb.FindById(product.ID.String())
 	assert.NotNil(t, err, "error should not be nil")
 }
+
+func TestProduct_CreateInBatches(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.Product{}, &entity.ProductRevision{}, &entity.ProductPrice{})
+	defer cleanup()
+
+	var products []*entity.Product
+	for i := 0; i < 25; i++ {
+		product, err := entity.NewProduct(fmt.Sprintf("product test %d", i), fmt.Sprintf("product description %d", i), float64(i+1))
+		if err != nil {
+			t.Error(err)
+		}
+		products = append(products, product)
+	}
+
+	productDb := NewProduct(db).WithContext(tenantContext(context.Background()))
+	err := productDb.CreateInBatches(products, 10)
+	assert.Nil(t, err, "error should be nil")
+
+	var count int64
+	db.Model(&entity.Product{}).Count(&count)
+	assert.Equal(t, int64(25), count, "all products should be created")
+
+	err = productDb.CreateInBatches(nil, 10)
+	assert.Nil(t, err, "empty batch should be a no-op")
+}
+
+func TestProduct_Transaction(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.Product{}, &entity.ProductRevision{}, &entity.ProductPrice{})
+	defer cleanup()
+
+	productDb := NewProduct(db).WithContext(tenantContext(context.Background()))
+	committed, _ := entity.NewProduct("committed", "committed description", 10)
+	rolledBack, _ := entity.NewProduct("rolled back", "rolled back description", 20)
+	missing, _ := entity.NewProduct("missing", "missing description", 30)
+
+	err := productDb.Transaction(func(tx ProductInterface) error {
+		return tx.Create(committed)
+	})
+	assert.Nil(t, err, "error should be nil")
+
+	err = productDb.Transaction(func(tx ProductInterface) error {
+		if err := tx.Create(rolledBack); err != nil {
+			return err
+		}
+		return tx.Delete(missing.ID.String())
+	})
+	assert.NotNil(t, err, "error should not be nil")
+
+	_, err = productDb.FindById(committed.ID.String())
+	assert.Nil(t, err, "committed product should exist")
+	_, err = productDb.FindById(rolledBack.ID.String())
+	assert.NotNil(t, err, "rolled back product should not exist")
+}
+
+func TestProduct_TenantIsolation(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.Product{}, &entity.ProductRevision{}, &entity.ProductPrice{})
+	defer cleanup()
+
+	tenantA, tenantB := entitypkg.NewID(), entitypkg.NewID()
+	productsA := NewProduct(db).WithContext(ContextWithTenant(context.Background(), tenantA))
+	productsB := NewProduct(db).WithContext(ContextWithTenant(context.Background(), tenantB))
+
+	product, _ := entity.NewProduct("product A", "product description", 10)
+	assert.Nil(t, productsA.Create(product), "error should be nil")
+	assert.Equal(t, tenantA, product.TenantID, "product should belong to the tenant of the context")
+
+	products, err := productsB.FindAll(0, 0, "asc")
+	assert.Nil(t, err, "error should be nil")
+	assert.Empty(t, products, "products of another tenant should not be listed")
+	_, err = productsB.FindById(product.ID.String())
+	assert.NotNil(t, err, "product of another tenant should not be found")
+
+	product.Name = "changed"
+	assert.NotNil(t, productsB.Update(product), "product of another tenant should not be updated")
+	assert.NotNil(t, productsB.Delete(product.ID.String()), "product of another tenant should not be deleted")
+
+	found, err := productsA.FindById(product.ID.String())
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, "product A", found.Name, "name should not change")
+
+	revisions, err := NewProductRevision(db).WithContext(ContextWithTenant(context.Background(), tenantB)).FindByProduct(product.ID.String())
+	assert.Nil(t, err, "error should be nil")
+	assert.Empty(t, revisions, "revisions of another tenant should not be listed")
+	prices, err := NewProductPrice(db).WithContext(ContextWithTenant(context.Background(), tenantA)).FindByProduct(product.ID.String())
+	assert.Nil(t, err, "error should be nil")
+	assert.Len(t, prices, 1, "price should inherit the tenant of the product")
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+// ProductPrice restringe o histórico de preços ao tenant do contexto. Os
+// preços herdam o tenant do produto.
+type ProductPrice struct {
+	DB       *gorm.DB
+	TenantID entitypkg.ID
+}
+
+func NewProductPrice(db *gorm.DB) *ProductPrice {
+	return &ProductPrice{DB: db}
+}
+
+func (pp *ProductPrice) WithContext(ctx context.Context) ProductPriceInterface {
+	return &ProductPrice{DB: pp.DB.WithContext(ctx), TenantID: tenantOf(ctx, pp.TenantID)}
+}
+
+func (pp *ProductPrice) FindByProduct(productID string) ([]*entity.ProductPrice, error) {
+	var prices []*entity.ProductPrice
+	if err := tenantScoped(pp.DB, pp.TenantID).Where("product_id = ?", productID).Order("effective_from desc").Find(&prices).Error; err != nil {
+		return nil, err
+	}
+	return prices, nil
+}
+
+// FindLowestSince devolve, para cada produto, o menor preço vigente em algum
+// momento desde since, incluindo o preço que já vigorava no início do período.
+func (pp *ProductPrice) FindLowestSince(productIDs []string, since time.Time) (map[string]float64, error) {
+	lowest := map[string]float64{}
+	if len(productIDs) == 0 {
+		return lowest, nil
+	}
+
+	type row struct {
+		ProductID string
+		Price     float64
+	}
+
+	var changed []row
+	err := tenantScoped(pp.DB, pp.TenantID).Model(&entity.ProductPrice{}).
+		Select("product_id, MIN(price) AS price").
+		Where("product_id IN ? AND effective_from >= ?", productIDs, since).
+		Group("product_id").
+		Scan(&changed).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var inEffect []row
+	err = tenantScoped(pp.DB.Table("product_prices AS p"), pp.TenantID).
+		Select("p.product_id, p.price").
+		Where("p.product_id IN ?", productIDs).
+		Where("p.effective_from = (?)", pp.DB.Table("product_prices AS q").
+			Select("MAX(q.effective_from)").
+			Where("q.product_id = p.product_id AND q.effective_from < ?", since)).
+		Scan(&inEffect).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range append(changed, inEffect...) {
+		if current, ok := lowest[r.ProductID]; !ok || r.Price < current {
+			lowest[r.ProductID] = r.Price
+		}
+	}
+	return lowest, nil
+}
+
+// createInitialProductPrice grava o preço vigente desde a criação dos produtos
+// anteriores ao histórico de preços, para que a primeira mudança não apague
+// o preço de antes dela do menor preço recente.
+func createInitialProductPrice(tx *gorm.DB, current *entity.Product) error {
+	var recorded int64
+	if err := tx.Model(&entity.ProductPrice{}).Where("product_id = ?", current.ID).Count(&recorded).Error; err != nil {
+		return err
+	}
+	if recorded > 0 {
+		return nil
+	}
+	return tx.Create(entity.NewProductPrice(current, current.CreatedAt)).Error
+}
+
+// createProductPrices grava o preço atual de cada produto como vigente a
+// partir de agora. Deve ser chamada na mesma transação que alterou os produtos.
+func createProductPrices(tx *gorm.DB, products ...*entity.Product) error {
+	now := time.Now()
+	prices := make([]*entity.ProductPrice, 0, len(products))
+	for _, product := range products {
+		prices = append(prices, entity.NewProductPrice(product, now))
+	}
+	return tx.CreateInBatches(prices, 100).Error
+}
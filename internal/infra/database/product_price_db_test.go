@@ -0,0 +1,100 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestProductPrice_RecordsPriceChanges(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.Product{}, &entity.ProductRevision{}, &entity.ProductPrice{})
+	defer cleanup()
+
+	productDb := NewProduct(db).WithContext(tenantContext(context.Background()))
+	priceDb := NewProductPrice(db).WithContext(tenantContext(context.Background()))
+
+	product, _ := entity.NewProduct("product test", "product description", 10)
+	assert.Nil(t, productDb.Create(product))
+
+	product.Name = "product test updated"
+	assert.Nil(t, productDb.Update(product))
+	product.Price = 8
+	assert.Nil(t, productDb.Update(product))
+
+	prices, err := priceDb.FindByProduct(product.ID.String())
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, 2, len(prices), "only price changes should be recorded")
+	assert.Equal(t, 8.0, prices[0].Price, "most recent price should come first")
+	assert.Equal(t, 10.0, prices[1].Price)
+	assert.False(t, prices[0].EffectiveFrom.Before(prices[1].EffectiveFrom), "effective dates should be ordered")
+}
+
+func TestProductPrice_RecordsPriceBeforeHistory(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.Product{}, &entity.ProductRevision{}, &entity.ProductPrice{})
+	defer cleanup()
+
+	productDb := NewProduct(db).WithContext(tenantContext(context.Background()))
+	priceDb := NewProductPrice(db).WithContext(tenantContext(context.Background()))
+
+	// Produto criado antes do histórico de preços.
+	product, _ := entity.NewProduct("product test", "product description", 10)
+	product.TenantID = testTenantID
+	product.CreatedAt = time.Now().Add(-48 * time.Hour)
+	assert.Nil(t, db.Create(product).Error)
+
+	product.Price = 12
+	assert.Nil(t, productDb.Update(product))
+
+	prices, err := priceDb.FindByProduct(product.ID.String())
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, 2, len(prices), "price before the first change should be recorded")
+	assert.Equal(t, 10.0, prices[1].Price)
+	assert.WithinDuration(t, product.CreatedAt, prices[1].EffectiveFrom, time.Second, "previous price should be in effect since the product was created")
+
+	lowest, err := priceDb.FindLowestSince([]string{product.ID.String()}, time.Now().Add(-24*time.Hour))
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, 10.0, lowest[product.ID.String()], "price before the first change should count")
+
+	product.Price = 11
+	assert.Nil(t, productDb.Update(product))
+	prices, err = priceDb.FindByProduct(product.ID.String())
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, 3, len(prices), "initial price should be recorded once")
+}
+
+func TestProductPrice_FindLowestSince(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.Product{}, &entity.ProductRevision{}, &entity.ProductPrice{})
+	defer cleanup()
+
+	now := time.Now()
+	since := now.Add(-entity.LowestPriceWindow)
+
+	inEffect, _ := entity.NewProduct("in effect", "description", 50)
+	changed, _ := entity.NewProduct("changed", "description", 50)
+	old, _ := entity.NewProduct("old", "description", 50)
+
+	history := []*entity.ProductPrice{
+		entity.NewProductPrice(&entity.Product{ID: inEffect.ID, TenantID: testTenantID, Price: 30}, now.Add(-60*24*time.Hour)),
+		entity.NewProductPrice(&entity.Product{ID: inEffect.ID, TenantID: testTenantID, Price: 40}, now.Add(-45*24*time.Hour)),
+		entity.NewProductPrice(&entity.Product{ID: inEffect.ID, TenantID: testTenantID, Price: 50}, now.Add(-10*24*time.Hour)),
+
+		entity.NewProductPrice(&entity.Product{ID: changed.ID, TenantID: testTenantID, Price: 70}, now.Add(-40*24*time.Hour)),
+		entity.NewProductPrice(&entity.Product{ID: changed.ID, TenantID: testTenantID, Price: 35}, now.Add(-20*24*time.Hour)),
+		entity.NewProductPrice(&entity.Product{ID: changed.ID, TenantID: testTenantID, Price: 50}, now.Add(-5*24*time.Hour)),
+
+		entity.NewProductPrice(&entity.Product{ID: old.ID, TenantID: testTenantID, Price: 50}, now.Add(-90*24*time.Hour)),
+	}
+	assert.Nil(t, db.Create(history).Error)
+
+	lowest, err := NewProductPrice(db).WithContext(tenantContext(context.Background())).FindLowestSince([]string{inEffect.ID.String(), changed.ID.String(), old.ID.String()}, since)
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, 40.0, lowest[inEffect.ID.String()], "price in effect at window start should count")
+	assert.Equal(t, 35.0, lowest[changed.ID.String()], "lowest price inside window should win")
+	assert.Equal(t, 50.0, lowest[old.ID.String()], "unchanged price should be the lowest")
+
+	lowest, err = NewProductPrice(db).WithContext(tenantContext(context.Background())).FindLowestSince(nil, since)
+	assert.Nil(t, err, "error should be nil")
+	assert.Empty(t, lowest, "no products should return an empty map")
+}
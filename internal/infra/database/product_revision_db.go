@@ -0,0 +1,60 @@
+package database
+
+import (
+	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"context"
+	"gorm.io/gorm"
+)
+
+// ProductRevision restringe as consultas ao tenant do contexto. As revisões
+// herdam o tenant do produto.
+type ProductRevision struct {
+	DB       *gorm.DB
+	TenantID entitypkg.ID
+}
+
+func NewProductRevision(db *gorm.DB) *ProductRevision {
+	return &ProductRevision{DB: db}
+}
+
+func (pr *ProductRevision) WithContext(ctx context.Context) ProductRevisionInterface {
+	return &ProductRevision{DB: pr.DB.WithContext(ctx), TenantID: tenantOf(ctx, pr.TenantID)}
+}
+
+func (pr *ProductRevision) FindByProduct(productID string) ([]*entity.ProductRevision, error) {
+	var revisions []*entity.ProductRevision
+	if err := tenantScoped(pr.DB, pr.TenantID).Where("product_id = ?", productID).Order("number asc").Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (pr *ProductRevision) FindByNumber(productID string, number int) (*entity.ProductRevision, error) {
+	var revision entity.ProductRevision
+	if err := tenantScoped(pr.DB, pr.TenantID).Where("product_id = ? AND number = ?", productID, number).First(&revision).Error; err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// createProductRevisions grava a próxima revisão de cada produto. Deve ser
+// chamada dentro da mesma transação que alterou os produtos.
+func createProductRevisions(tx *gorm.DB, products ...*entity.Product) error {
+	actor := AuditFromContext(tx.Statement.Context).Actor
+
+	revisions := make([]*entity.ProductRevision, 0, len(products))
+	for _, product := range products {
+		var last int
+		err := tx.Model(&entity.ProductRevision{}).
+			Where("product_id = ?", product.ID).
+			Select("COALESCE(MAX(number), 0)").
+			Scan(&last).Error
+		if err != nil {
+			return err
+		}
+		revisions = append(revisions, entity.NewProductRevision(product, last+1, actor))
+	}
+
+	return tx.CreateInBatches(revisions, 100).Error
+}
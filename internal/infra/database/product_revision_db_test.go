@@ -0,0 +1,73 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestProductRevision_RecordsEveryChange(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.Product{}, &entity.ProductRevision{}, &entity.ProductPrice{})
+	defer cleanup()
+
+	ctx := ContextWithAudit(tenantContext(context.Background()), AuditMetadata{Actor: "user-1"})
+	productDb := NewProduct(db).WithContext(ctx)
+	revisionDb := NewProductRevision(db).WithContext(tenantContext(context.Background()))
+
+	product, _ := entity.NewProduct("product test", "product description", 10)
+	err := productDb.Create(product)
+	assert.Nil(t, err, "error should be nil")
+
+	product.Price = 20
+	assert.Nil(t, productDb.Update(product))
+	product.Name = "product test updated"
+	assert.Nil(t, productDb.Update(product))
+
+	revisions, err := revisionDb.FindByProduct(product.ID.String())
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, 3, len(revisions), "create and each update should be a revision")
+	for i, revision := range revisions {
+		assert.Equal(t, i+1, revision.Number, "revisions should be numbered in order")
+		assert.Equal(t, "user-1", revision.CreatedBy, "author should come from context")
+	}
+	assert.Equal(t, 10.0, revisions[0].Price)
+	assert.Equal(t, 20.0, revisions[1].Price)
+	assert.Equal(t, "product test updated", revisions[2].Name)
+}
+
+func TestProductRevision_FindByNumber(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.Product{}, &entity.ProductRevision{}, &entity.ProductPrice{})
+	defer cleanup()
+
+	productDb := NewProduct(db).WithContext(tenantContext(context.Background()))
+	revisionDb := NewProductRevision(db).WithContext(tenantContext(context.Background()))
+
+	product, _ := entity.NewProduct("product test", "product description", 10)
+	assert.Nil(t, productDb.Create(product))
+	product.Price = 30
+	assert.Nil(t, productDb.Update(product))
+
+	revision, err := revisionDb.FindByNumber(product.ID.String(), 2)
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, 30.0, revision.Price, "price should be the same")
+
+	_, err = revisionDb.FindByNumber(product.ID.String(), 3)
+	assert.NotNil(t, err, "error should not be nil")
+}
+
+func TestProductRevision_CreateInBatches(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.Product{}, &entity.ProductRevision{}, &entity.ProductPrice{})
+	defer cleanup()
+
+	p1, _ := entity.NewProduct("product 1", "description 1", 10)
+	p2, _ := entity.NewProduct("product 2", "description 2", 20)
+	assert.Nil(t, NewProduct(db).WithContext(tenantContext(context.Background())).CreateInBatches([]*entity.Product{p1, p2}, 10))
+
+	revisionDb := NewProductRevision(db).WithContext(tenantContext(context.Background()))
+	for _, product := range []*entity.Product{p1, p2} {
+		revisions, err := revisionDb.FindByProduct(product.ID.String())
+		assert.Nil(t, err, "error should be nil")
+		assert.Equal(t, 1, len(revisions), "each product should have its first revision")
+	}
+}
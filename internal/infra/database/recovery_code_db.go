@@ -0,0 +1,68 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+var ErrRecoveryCodeInvalid = errors.New("invalid recovery code")
+
+type RecoveryCode struct {
+	DB *gorm.DB
+}
+
+func NewRecoveryCode(db *gorm.DB) *RecoveryCode {
+	return &RecoveryCode{DB: db}
+}
+
+func (rc *RecoveryCode) WithContext(ctx context.Context) RecoveryCodeInterface {
+	return NewRecoveryCode(rc.DB.WithContext(ctx))
+}
+
+// Replace descarta os códigos anteriores do usuário e grava os novos.
+func (rc *RecoveryCode) Replace(userID string, codes []*entity.RecoveryCode) error {
+	return rc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&entity.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(codes).Error
+	})
+}
+
+// Use consome o código do usuário. Os hashes têm sal, então o código é
+// conferido com cada código ainda não usado. A condição em used_at garante o
+// uso único mesmo com requisições concorrentes.
+func (rc *RecoveryCode) Use(userID, code string, now time.Time) error {
+	var codes []*entity.RecoveryCode
+	if err := rc.DB.Where("user_id = ? AND used_at IS NULL", userID).Find(&codes).Error; err != nil {
+		return err
+	}
+	for _, recoveryCode := range codes {
+		if !recoveryCode.Matches(code) {
+			continue
+		}
+		result := rc.DB.Model(&entity.RecoveryCode{}).
+			Where("id = ? AND used_at IS NULL", recoveryCode.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRecoveryCodeInvalid
+		}
+		return nil
+	}
+	return ErrRecoveryCodeInvalid
+}
+
+func (rc *RecoveryCode) CountUnused(userID string) (int64, error) {
+	var count int64
+	err := rc.DB.Model(&entity.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
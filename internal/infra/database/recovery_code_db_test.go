@@ -0,0 +1,43 @@
+package database
+
+import (
+	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRecoveryCode_Use(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.RecoveryCode{})
+	defer cleanup()
+
+	recoveryDB := NewRecoveryCode(db)
+	userID := entitypkg.NewID()
+	codes, plain, _ := entity.NewRecoveryCodes(userID)
+	assert.Nil(t, recoveryDB.Replace(userID.String(), codes), "error should be nil")
+
+	assert.Nil(t, recoveryDB.Use(userID.String(), plain[0], time.Now()), "first use should succeed")
+	assert.ErrorIs(t, recoveryDB.Use(userID.String(), plain[0], time.Now()), ErrRecoveryCodeInvalid, "code should be single use")
+	assert.ErrorIs(t, recoveryDB.Use(entitypkg.NewID().String(), plain[1], time.Now()), ErrRecoveryCodeInvalid, "code should belong to the user")
+
+	count, err := recoveryDB.CountUnused(userID.String())
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, int64(entity.RecoveryCodeCount-1), count)
+}
+
+func TestRecoveryCode_Replace(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.RecoveryCode{})
+	defer cleanup()
+
+	recoveryDB := NewRecoveryCode(db)
+	userID := entitypkg.NewID()
+	oldCodes, oldPlain, _ := entity.NewRecoveryCodes(userID)
+	assert.Nil(t, recoveryDB.Replace(userID.String(), oldCodes))
+
+	newCodes, newPlain, _ := entity.NewRecoveryCodes(userID)
+	assert.Nil(t, recoveryDB.Replace(userID.String(), newCodes))
+
+	assert.ErrorIs(t, recoveryDB.Use(userID.String(), oldPlain[0], time.Now()), ErrRecoveryCodeInvalid, "old codes should be discarded")
+	assert.Nil(t, recoveryDB.Use(userID.String(), newPlain[0], time.Now()))
+}
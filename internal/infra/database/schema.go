@@ -0,0 +1,52 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Models lista as entidades persistidas pela aplicação, na ordem em que são
+// migradas.
+func Models() []interface{} {
+	return []interface{}{
+		&entity.User{},
+		&entity.Product{},
+		&entity.IdempotencyRecord{},
+		&entity.AuditEntry{},
+		&entity.ProductRevision{},
+		&entity.ProductPrice{},
+		&entity.UserToken{},
+		&entity.RecoveryCode{},
+		&entity.APIKey{},
+		&entity.OAuthClient{},
+		&entity.Session{},
+		&entity.ComplianceRecord{},
+		&entity.Tenant{},
+	}
+}
+
+// CheckSchema verifica se o banco possui todas as tabelas e colunas esperadas
+// pelas entidades, isto é, se as migrações desta versão foram aplicadas.
+func CheckSchema(db *gorm.DB) error {
+	migrator := db.Migrator()
+	for _, model := range Models() {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+		if !migrator.HasTable(model) {
+			return fmt.Errorf("table %s is missing", stmt.Schema.Table)
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			if !migrator.HasColumn(model, field.DBName) {
+				return fmt.Errorf("column %s.%s is missing", stmt.Schema.Table, field.DBName)
+			}
+		}
+	}
+	return nil
+}
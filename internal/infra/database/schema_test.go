@@ -0,0 +1,34 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSchema(t *testing.T) {
+	db, cleanup := initDBTest(t, Models()...)
+	defer cleanup()
+
+	assert.Nil(t, CheckSchema(db), "migrated schema should be valid")
+}
+
+func TestCheckSchemaMissingTable(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.User{}, &entity.Product{})
+	defer cleanup()
+
+	err := CheckSchema(db)
+	assert.NotNil(t, err, "error should not be nil")
+	assert.Contains(t, err.Error(), "idempotency_records")
+}
+
+func TestCheckSchemaMissingColumn(t *testing.T) {
+	db, cleanup := initDBTest(t, Models()...)
+	defer cleanup()
+	assert.Nil(t, db.Migrator().DropColumn(&entity.Product{}, "price"))
+
+	err := CheckSchema(db)
+	assert.NotNil(t, err, "error should not be nil")
+	assert.Contains(t, err.Error(), "products.price")
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+type Session struct {
+	DB *gorm.DB
+}
+
+func NewSession(db *gorm.DB) *Session {
+	return &Session{DB: db}
+}
+
+func (s *Session) WithContext(ctx context.Context) SessionInterface {
+	return NewSession(s.DB.WithContext(ctx))
+}
+
+func (s *Session) Create(session *entity.Session) error {
+	return s.DB.Create(session).Error
+}
+
+func (s *Session) FindByID(id string) (*entity.Session, error) {
+	var session entity.Session
+	if err := s.DB.Where("id = ?", id).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// FindByUser lista todas as sessões do usuário, inclusive as encerradas e
+// expiradas, da mais recente para a mais antiga.
+func (s *Session) FindByUser(userID string) ([]*entity.Session, error) {
+	var sessions []*entity.Session
+	if err := s.DB.Where("user_id = ?", userID).Order("created_at desc").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// FindActiveByUser lista as sessões não encerradas e não expiradas, da mais
+// recente para a mais antiga.
+func (s *Session) FindActiveByUser(userID string, now time.Time) ([]*entity.Session, error) {
+	var sessions []*entity.Session
+	err := s.DB.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at desc").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// Touch registra o último acesso da sessão.
+func (s *Session) Touch(session *entity.Session, now time.Time) error {
+	err := s.DB.Model(&entity.Session{}).Where("id = ?", session.ID).UpdateColumn("last_seen_at", now).Error
+	if err != nil {
+		return err
+	}
+	session.LastSeenAt = now
+	return nil
+}
+
+// Revoke encerra uma sessão ativa do usuário. Devolve gorm.ErrRecordNotFound
+// quando a sessão não existe, é de outro usuário ou já foi encerrada.
+func (s *Session) Revoke(userID, id string, now time.Time) error {
+	result := s.DB.Model(&entity.Session{}).
+		Where("user_id = ? AND id = ? AND revoked_at IS NULL", userID, id).
+		Update("revoked_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RevokeAll encerra todas as sessões ativas do usuário, exceto exceptID quando
+// informado, e devolve quantas foram encerradas.
+func (s *Session) RevokeAll(userID string, now time.Time, exceptID string) (int64, error) {
+	query := s.DB.Model(&entity.Session{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+	if exceptID != "" {
+		query = query.Where("id <> ?", exceptID)
+	}
+	result := query.Update("revoked_at", now)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+func TestSession_FindActiveByUser(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.Session{})
+	defer cleanup()
+
+	sessionDB := NewSession(db)
+	userID := entitypkg.NewID()
+	active := entity.NewSession(userID, "curl", "10.0.0.1", time.Hour)
+	expired := entity.NewSession(userID, "curl", "10.0.0.1", -time.Minute)
+	other := entity.NewSession(entitypkg.NewID(), "curl", "10.0.0.2", time.Hour)
+	for _, session := range []*entity.Session{active, expired, other} {
+		assert.Nil(t, sessionDB.Create(session))
+	}
+
+	sessions, err := sessionDB.FindActiveByUser(userID.String(), time.Now())
+	assert.Nil(t, err)
+	assert.Len(t, sessions, 1, "only active sessions of the user should be listed")
+	assert.Equal(t, active.ID, sessions[0].ID)
+}
+
+func TestSession_FindByUser(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.Session{})
+	defer cleanup()
+
+	sessionDB := NewSession(db)
+	userID := entitypkg.NewID()
+	active := entity.NewSession(userID, "curl", "10.0.0.1", time.Hour)
+	expired := entity.NewSession(userID, "curl", "10.0.0.1", -time.Minute)
+	other := entity.NewSession(entitypkg.NewID(), "curl", "10.0.0.2", time.Hour)
+	for _, session := range []*entity.Session{active, expired, other} {
+		assert.Nil(t, sessionDB.Create(session))
+	}
+
+	sessions, err := sessionDB.FindByUser(userID.String())
+	assert.Nil(t, err)
+	assert.Len(t, sessions, 2, "expired sessions of the user should be listed too")
+}
+
+func TestSession_Revoke(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.Session{})
+	defer cleanup()
+
+	sessionDB := NewSession(db)
+	userID := entitypkg.NewID()
+	session := entity.NewSession(userID, "curl", "10.0.0.1", time.Hour)
+	assert.Nil(t, sessionDB.Create(session))
+
+	now := time.Now()
+	assert.ErrorIs(t, sessionDB.Revoke(entitypkg.NewID().String(), session.ID.String(), now), gorm.ErrRecordNotFound, "other users should not revoke the session")
+	assert.Nil(t, sessionDB.Revoke(userID.String(), session.ID.String(), now))
+	assert.ErrorIs(t, sessionDB.Revoke(userID.String(), session.ID.String(), now), gorm.ErrRecordNotFound, "revoking twice should report not found")
+
+	found, err := sessionDB.FindByID(session.ID.String())
+	assert.Nil(t, err)
+	assert.False(t, found.IsActive(now))
+}
+
+func TestSession_RevokeAll(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.Session{})
+	defer cleanup()
+
+	sessionDB := NewSession(db)
+	userID := entitypkg.NewID()
+	var sessions []*entity.Session
+	for i := 0; i < 3; i++ {
+		session := entity.NewSession(userID, "curl", "10.0.0.1", time.Hour)
+		assert.Nil(t, sessionDB.Create(session))
+		sessions = append(sessions, session)
+	}
+
+	now := time.Now()
+	revoked, err := sessionDB.RevokeAll(userID.String(), now, sessions[0].ID.String())
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), revoked)
+
+	active, err := sessionDB.FindActiveByUser(userID.String(), now)
+	assert.Nil(t, err)
+	assert.Len(t, active, 1, "the excepted session should stay active")
+	assert.Equal(t, sessions[0].ID, active[0].ID)
+
+	revoked, err = sessionDB.RevokeAll(userID.String(), now, "")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), revoked)
+}
+
+func TestSession_Touch(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.Session{})
+	defer cleanup()
+
+	sessionDB := NewSession(db)
+	session := entity.NewSession(entitypkg.NewID(), "curl", "10.0.0.1", time.Hour)
+	assert.Nil(t, sessionDB.Create(session))
+
+	later := session.LastSeenAt.Add(5 * time.Minute)
+	assert.Nil(t, sessionDB.Touch(session, later))
+
+	found, err := sessionDB.FindByID(session.ID.String())
+	assert.Nil(t, err)
+	assert.WithinDuration(t, later, found.LastSeenAt, time.Millisecond)
+}
@@ -0,0 +1,145 @@
+package database
+
+import (
+	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+)
+
+// ErrTenantRequired indica uma operação sem tenant num repositório isolado
+// por tenant. Sem tenant nada é lido nem gravado: uma falha na resolução do
+// tenant nunca pode expor os dados de todos os tenants.
+var ErrTenantRequired = errors.New("tenant is required")
+
+type tenantContextKey struct{}
+
+// ContextWithTenant define o tenant usado pelos repositórios criados com
+// WithContext a partir do contexto.
+func ContextWithTenant(ctx context.Context, tenantID entitypkg.ID) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext devolve o tenant do contexto, ou o ID zero quando não há.
+func TenantFromContext(ctx context.Context) entitypkg.ID {
+	if ctx == nil {
+		return entitypkg.ID{}
+	}
+	tenantID, _ := ctx.Value(tenantContextKey{}).(entitypkg.ID)
+	return tenantID
+}
+
+// TenantScope restringe a consulta aos registros do tenant.
+func TenantScope(tenantID entitypkg.ID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("tenant_id = ?", tenantID)
+	}
+}
+
+// tenantScoped aplica TenantScope. Sem tenant a consulta falha com
+// ErrTenantRequired em vez de alcançar todos os tenants.
+func tenantScoped(db *gorm.DB, tenantID entitypkg.ID) *gorm.DB {
+	if tenantID == (entitypkg.ID{}) {
+		tx := db.Session(&gorm.Session{})
+		_ = tx.AddError(ErrTenantRequired)
+		return tx
+	}
+	return db.Scopes(TenantScope(tenantID))
+}
+
+// requireTenant recusa gravações sem tenant.
+func requireTenant(tenantID entitypkg.ID) error {
+	if tenantID == (entitypkg.ID{}) {
+		return ErrTenantRequired
+	}
+	return nil
+}
+
+// tenantOf escolhe o tenant do contexto e, na falta dele, o já definido no
+// repositório.
+func tenantOf(ctx context.Context, current entitypkg.ID) entitypkg.ID {
+	if tenantID := TenantFromContext(ctx); tenantID != (entitypkg.ID{}) {
+		return tenantID
+	}
+	return current
+}
+
+type Tenant struct {
+	DB *gorm.DB
+}
+
+func NewTenant(db *gorm.DB) *Tenant {
+	return &Tenant{DB: db}
+}
+
+func (t *Tenant) WithContext(ctx context.Context) TenantInterface {
+	return NewTenant(t.DB.WithContext(ctx))
+}
+
+func (t *Tenant) Create(tenant *entity.Tenant) error {
+	return t.DB.Create(tenant).Error
+}
+
+func (t *Tenant) FindAll() ([]*entity.Tenant, error) {
+	var tenants []*entity.Tenant
+	if err := t.DB.Order("created_at asc").Find(&tenants).Error; err != nil {
+		return nil, err
+	}
+	return tenants, nil
+}
+
+func (t *Tenant) FindByID(id string) (*entity.Tenant, error) {
+	var tenant entity.Tenant
+	if err := t.DB.Where("id = ?", id).First(&tenant).Error; err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+func (t *Tenant) FindBySlug(slug string) (*entity.Tenant, error) {
+	var tenant entity.Tenant
+	if err := t.DB.Where("slug = ?", slug).First(&tenant).Error; err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+// EnsureDefaultTenant cria o tenant padrão quando ainda não existe e atribui
+// a ele os registros sem tenant. Deve rodar antes de
+// RegisterComplianceCallbacks, que impede a atualização do log de
+// conformidade.
+func EnsureDefaultTenant(db *gorm.DB, name string) (*entity.Tenant, error) {
+	var tenant *entity.Tenant
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		tenant, err = NewTenant(tx).FindBySlug(entity.DefaultTenantSlug)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if tenant, err = entity.NewTenant(name, entity.DefaultTenantSlug); err != nil {
+				return err
+			}
+			err = tx.Create(tenant).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		models := []interface{}{
+			&entity.User{}, &entity.Product{}, &entity.ProductRevision{}, &entity.ProductPrice{},
+			&entity.AuditEntry{}, &entity.OAuthClient{}, &entity.ComplianceRecord{},
+		}
+		for _, model := range models {
+			err := tx.Model(model).
+				Where("tenant_id IS NULL OR tenant_id = ?", entitypkg.ID{}).
+				UpdateColumn("tenant_id", tenant.ID).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tenant, nil
+}
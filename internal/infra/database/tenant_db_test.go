@@ -0,0 +1,83 @@
+package database
+
+import (
+	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTenant_FindBySlug(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.Tenant{})
+	defer cleanup()
+
+	tenant, err := entity.NewTenant("Acme", "acme")
+	assert.Nil(t, err, "error should be nil")
+	tenantDB := NewTenant(db)
+	assert.Nil(t, tenantDB.Create(tenant), "error should be nil")
+
+	found, err := tenantDB.FindBySlug("acme")
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, tenant.ID, found.ID, "ID should be the same")
+
+	duplicated, _ := entity.NewTenant("Acme 2", "acme")
+	assert.NotNil(t, tenantDB.Create(duplicated), "slug should be unique")
+
+	_, err = tenantDB.FindBySlug("unknown")
+	assert.NotNil(t, err, "unknown slug should not be found")
+}
+
+func TestEnsureDefaultTenant(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.Tenant{}, &entity.User{}, &entity.Product{}, &entity.ProductRevision{},
+		&entity.ProductPrice{}, &entity.AuditEntry{}, &entity.OAuthClient{}, &entity.ComplianceRecord{})
+	defer cleanup()
+
+	user, _ := entity.NewUser("test", "test@gmail.com", "test123")
+	assert.Nil(t, db.Create(user).Error, "error should be nil")
+	other, _ := entity.NewUser("other", "other@gmail.com", "test123")
+	other.TenantID = entitypkg.NewID()
+	assert.Nil(t, db.Create(other).Error, "error should be nil")
+
+	tenant, err := EnsureDefaultTenant(db, "Default")
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, entity.DefaultTenantSlug, tenant.Slug)
+
+	var found entity.User
+	assert.Nil(t, db.Where("id = ?", user.ID).First(&found).Error)
+	assert.Equal(t, tenant.ID, found.TenantID, "records without tenant should be assigned to the default tenant")
+	var foundOther entity.User
+	assert.Nil(t, db.Where("id = ?", other.ID).First(&foundOther).Error)
+	assert.Equal(t, other.TenantID, foundOther.TenantID, "records of other tenants should not change")
+
+	again, err := EnsureDefaultTenant(db, "Default")
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, tenant.ID, again.ID, "default tenant should be created only once")
+}
+
+func TestTenantScoped_RequiresTenant(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.User{}, &entity.Product{}, &entity.ProductRevision{}, &entity.ProductPrice{})
+	defer cleanup()
+
+	user, _ := entity.NewUser("test", "test@gmail.com", "test123")
+	assert.Nil(t, NewUser(db).WithContext(tenantContext(context.Background())).Create(user))
+
+	userDB := NewUser(db)
+	assert.ErrorIs(t, userDB.Create(user), ErrTenantRequired, "users should not be created without tenant")
+	_, _, err := userDB.FindAll(UserFilter{})
+	assert.ErrorIs(t, err, ErrTenantRequired, "users should not be listed without tenant")
+	_, err = userDB.FindById(user.ID.String())
+	assert.ErrorIs(t, err, ErrTenantRequired, "users should not be found without tenant")
+	_, err = userDB.FindByEmail(user.Email)
+	assert.ErrorIs(t, err, ErrTenantRequired, "users should not be found without tenant")
+	assert.ErrorIs(t, userDB.Delete(user.ID.String()), ErrTenantRequired, "users should not be deleted without tenant")
+
+	product, _ := entity.NewProduct("product test", "product description", 10)
+	productDB := NewProduct(db)
+	assert.ErrorIs(t, productDB.Create(product), ErrTenantRequired, "products should not be created without tenant")
+	_, err = productDB.FindAll(0, 0, "asc")
+	assert.ErrorIs(t, err, ErrTenantRequired, "products should not be listed without tenant")
+
+	_, err = NewUser(db).WithContext(tenantContext(context.Background())).FindById(user.ID.String())
+	assert.Nil(t, err, "queries with tenant should not be affected")
+}
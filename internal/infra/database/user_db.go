@@ -2,30 +2,134 @@ package database
 
 import (
 	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"context"
 	"gorm.io/gorm"
+	"strings"
+	"time"
 )
 
+// Situações aceitas no filtro de usuários, além das situações da conta
+// definidas em entity.
+const (
+	UserStatusVerified   = "verified"
+	UserStatusUnverified = "unverified"
+	UserStatusLocked     = "locked"
+)
+
+// Campos aceitos na ordenação de usuários.
+var UserSortFields = []string{"created_at", "name", "email"}
+
+type UserFilter struct {
+	Query       string
+	Role        string
+	Status      string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	Sort        string
+	Order       string
+	Page        int
+	Limit       int
+}
+
+// likeEscaper escapa os curingas do LIKE para que a busca seja literal.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// User restringe todas as operações ao TenantID, que vem do contexto
+// recebido em WithContext.
 type User struct {
-	DB *gorm.DB
+	DB       *gorm.DB
+	TenantID entitypkg.ID
 }
 
 func NewUser(db *gorm.DB) *User {
 	return &User{DB: db}
 }
 
+func (u *User) WithContext(ctx context.Context) UserInterface {
+	return &User{DB: u.DB.WithContext(ctx), TenantID: tenantOf(ctx, u.TenantID)}
+}
+
+func (u *User) scoped() *gorm.DB {
+	return tenantScoped(u.DB, u.TenantID)
+}
+
 func (u *User) Create(user *entity.User) error {
+	if err := requireTenant(u.TenantID); err != nil {
+		return err
+	}
+	user.TenantID = u.TenantID
 	return u.DB.Create(user).Error
 }
 
-func (u *User) FindAll() ([]*entity.User, error) {
+// FindAll busca os usuários que atendem ao filtro e devolve também o total
+// de registros encontrados, sem considerar a paginação.
+func (u *User) FindAll(filter UserFilter) ([]*entity.User, int64, error) {
+	query := u.scoped().Model(&entity.User{})
+	if filter.Query != "" {
+		term := "%" + likeEscaper.Replace(strings.ToLower(filter.Query)) + "%"
+		query = query.Where(`(LOWER(name) LIKE ? ESCAPE '\' OR LOWER(email) LIKE ? ESCAPE '\')`, term, term)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	switch filter.Status {
+	case UserStatusVerified:
+		query = query.Where("email_verified_at IS NOT NULL")
+	case UserStatusUnverified:
+		query = query.Where("email_verified_at IS NULL")
+	case UserStatusLocked:
+		query = query.Where("locked_until > ?", time.Now())
+	case entity.UserStatusActive, entity.UserStatusSuspended, entity.UserStatusDeactivated:
+		query = query.Where("status = ?", filter.Status)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		query = query.Where("created_at >= ?", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		query = query.Where("created_at <= ?", filter.CreatedTo)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	sort := "created_at"
+	for _, field := range UserSortFields {
+		if filter.Sort == field {
+			sort = field
+		}
+	}
+	order := "asc"
+	if filter.Order == "desc" {
+		order = "desc"
+	}
+	// O id desempata registros iguais para que as páginas sejam estáveis.
+	query = query.Order(sort + " " + order).Order("id " + order)
+	if filter.Page != 0 && filter.Limit != 0 {
+		query = query.Offset((filter.Page - 1) * filter.Limit).Limit(filter.Limit)
+	}
+
 	var users []*entity.User
-	if err := u.DB.Find(&users).Error; err != nil {
-		return nil, err
+	if err := query.Find(&users).Error; err != nil {
+		return nil, 0, err
 	}
-	return users, nil
+	return users, total, nil
 }
 
 func (u *User) FindById(id string) (*entity.User, error) {
+	var user entity.User
+	if err := u.scoped().Where("id = ?", id).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindByIdAcrossTenants busca o usuário em qualquer tenant. É usado apenas
+// nos fluxos identificados por um token, como a confirmação de e-mail, que
+// seguem no tenant do usuário encontrado.
+func (u *User) FindByIdAcrossTenants(id string) (*entity.User, error) {
 	var user entity.User
 	if err := u.DB.Where("id = ?", id).First(&user).Error; err != nil {
 		return nil, err
@@ -35,24 +139,89 @@ func (u *User) FindById(id string) (*entity.User, error) {
 
 func (u *User) FindByEmail(email string) (*entity.User, error) {
 	var user entity.User
-	if err := u.DB.Where("email = ?", email).First(&user).Error; err != nil {
+	if err := u.scoped().Where("email = ?", email).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
 func (u *User) Update(user *entity.User) error {
-	_, err := u.FindById(user.ID.String())
+	current, err := u.FindById(user.ID.String())
 	if err != nil {
 		return err
 	}
+	// O usuário nunca muda de tenant.
+	user.TenantID = current.TenantID
 	return u.DB.Save(user).Error
 }
 
+// RegisterLoginFailure soma uma falha de login ao usuário e o bloqueia por
+// lockout ao atingir maxAttempts. A contagem é feita pelo próprio banco, para
+// que falhas simultâneas não se percam, e só altera as colunas do bloqueio.
+// Um bloqueio vencido recomeça a contagem e uma conta bloqueada não muda.
+// Devolve true quando esta falha bloqueou a conta.
+func (u *User) RegisterLoginFailure(id string, now time.Time, maxAttempts int, lockout time.Duration) (bool, error) {
+	result := u.scoped().Model(&entity.User{}).
+		Where("id = ? AND (locked_until IS NULL OR locked_until <= ?)", id, now).
+		UpdateColumns(map[string]interface{}{
+			"failed_login_attempts": gorm.Expr("CASE WHEN locked_until IS NULL THEN failed_login_attempts + 1 ELSE 1 END"),
+			"locked_until":          nil,
+		})
+	if result.Error != nil || result.RowsAffected == 0 || maxAttempts <= 0 {
+		return false, result.Error
+	}
+
+	result = u.scoped().Model(&entity.User{}).
+		Where("id = ? AND failed_login_attempts >= ? AND locked_until IS NULL", id, maxAttempts).
+		UpdateColumn("locked_until", now.Add(lockout))
+	return result.RowsAffected == 1, result.Error
+}
+
 func (u *User) Delete(id string) error {
 	user, err := u.FindById(id)
 	if err != nil {
 		return err
 	}
-	return u.DB.Delete(user).Error
+	return u.scoped().Delete(user).Error
+}
+
+// Erase grava o usuário já anonimizado e apaga, na mesma transação, as
+// sessões, chaves de API, tokens e códigos de recuperação dele. Os dados
+// pessoais também são retirados das entradas de auditoria sobre o usuário,
+// enquanto as entradas de que ele é autor mantêm o ID. O registro de
+// conformidade é gravado junto, para que nenhum apagamento fique sem registro.
+func (u *User) Erase(user *entity.User, record *entity.ComplianceRecord) error {
+	current, err := u.FindById(user.ID.String())
+	if err != nil {
+		return err
+	}
+	user.TenantID = current.TenantID
+	record.TenantID = current.TenantID
+	return u.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+
+		id := user.ID.String()
+		for _, model := range []interface{}{&entity.Session{}, &entity.APIKey{}, &entity.UserToken{}, &entity.RecoveryCode{}} {
+			if err := tx.Where("user_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		var entries []*entity.AuditEntry
+		if err := tx.Where("entity_type = ? AND entity_id = ?", "users", id).Find(&entries).Error; err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if !entry.Changes.Redact(entity.UserPersonalFields...) {
+				continue
+			}
+			if err := tx.Model(&entity.AuditEntry{}).Where("id = ?", entry.ID).UpdateColumn("changes", entry.Changes).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(record).Error
+	})
 }
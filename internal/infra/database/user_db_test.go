@@ -1,9 +1,12 @@
 package database
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -12,7 +15,7 @@ func TestUser_Create(t *testing.T) {
 	defer cleanup()
 
 	user, _ := entity.NewUser("test", "test1@gmail.com", "test123")
-	userDB := NewUser(db)
+	userDB := NewUser(db).WithContext(tenantContext(context.Background()))
 
 	err := userDB.Create(user)
 	assert.Nil(t, err, "error should be nil")
@@ -34,7 +37,7 @@ func TestUser_FindByEmail(t *testing.T) {
 	defer cleanup()
 
 	user, _ := entity.NewUser("test", "test@gmail.com", "test123")
-	userDB := NewUser(db)
+	userDB := NewUser(db).WithContext(tenantContext(context.Background()))
 
 	err := userDB.Create(user)
 	assert.Nil(t, err, "error should be nil")
@@ -51,7 +54,7 @@ func TestUser_FindById(t *testing.T) {
 	db, cleanup := initDBTest(t, &entity.User{})
 	defer cleanup()
 	user, _ := entity.NewUser("test", "test@gmail.com", "test123")
-	userDB := NewUser(db)
+	userDB := NewUser(db).WithContext(tenantContext(context.Background()))
 
 	err := userDB.Create(user)
 	assert.Nil(t, err, "error should be nil")
@@ -66,7 +69,7 @@ func TestUser_Delete(t *testing.T) {
 	defer cleanup()
 
 	user, _ := entity.NewUser("test", "user@gmail.com", "test123")
-	userDB := NewUser(db)
+	userDB := NewUser(db).WithContext(tenantContext(context.Background()))
 
 	err := userDB.Create(user)
 	assert.Nil(t, err, "error should be nil")
@@ -92,7 +95,7 @@ func TestUser_Update(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	userDB := NewUser(db)
+	userDB := NewUser(db).WithContext(tenantContext(context.Background()))
 	err = userDB.Create(user)
 	assert.Nil(t, err, "error should be nil")
 	assert.NotNil(t, user, "user should not be nil")
@@ -112,3 +115,221 @@ func TestUser_Update(t *testing.T) {
 	assert.Equal(t, user.Password, updatededUser.Password, "password should be the same")
 
 }
+
+func TestUser_RegisterLoginFailure(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.User{})
+	defer cleanup()
+
+	user, _ := entity.NewUser("test", "user@gmail.com", "test123")
+	userDB := NewUser(db).WithContext(tenantContext(context.Background()))
+	assert.Nil(t, userDB.Create(user))
+	id := user.ID.String()
+	now := time.Now()
+
+	for i := 1; i < 3; i++ {
+		locked, err := userDB.RegisterLoginFailure(id, now, 3, time.Minute)
+		assert.Nil(t, err)
+		assert.False(t, locked)
+	}
+	locked, err := userDB.RegisterLoginFailure(id, now, 3, time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, locked, "user should be locked at max attempts")
+
+	locked, err = userDB.RegisterLoginFailure(id, now, 3, time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, locked)
+	found, err := userDB.FindById(id)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, found.FailedLoginAttempts, "locked user should not count failures")
+	assert.True(t, found.IsLocked(now))
+
+	later := now.Add(2 * time.Minute)
+	locked, err = userDB.RegisterLoginFailure(id, later, 3, time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, locked)
+	found, err = userDB.FindById(id)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, found.FailedLoginAttempts, "expired lockout should reset attempts")
+	assert.Nil(t, found.LockedUntil)
+}
+
+func TestUser_FindAll(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.User{})
+	defer cleanup()
+
+	userDB := NewUser(db).WithContext(tenantContext(context.Background()))
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	names := []string{"Alice", "Bob", "Carol", "Dave", "Eve_Admin"}
+	for i, name := range names {
+		user, err := entity.NewUser(name, name+"@example.com", "test123")
+		assert.Nil(t, err, "error should be nil")
+		user.CreatedAt = base.Add(time.Duration(i) * 24 * time.Hour)
+		if i%2 == 0 {
+			user.VerifyEmail(user.CreatedAt)
+		}
+		if name == "Eve_Admin" {
+			user.Role = entity.RoleAdmin
+		}
+		assert.Nil(t, userDB.Create(user), "error should be nil")
+	}
+	assert.Nil(t, db.Model(&entity.User{}).Where("name = ?", "Bob").Update("status", entity.UserStatusSuspended).Error)
+	locked := time.Now().Add(time.Hour)
+	assert.Nil(t, db.Model(&entity.User{}).Where("name = ?", "Dave").Update("locked_until", locked).Error)
+
+	users, total, err := userDB.FindAll(UserFilter{Page: 2, Limit: 2})
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, int64(5), total, "total should ignore pagination")
+	assert.Len(t, users, 2, "page should be limited")
+	assert.Equal(t, "Carol", users[0].Name, "users should be sorted by creation date")
+
+	users, _, err = userDB.FindAll(UserFilter{Sort: "name", Order: "desc", Page: 1, Limit: 1})
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, "Eve_Admin", users[0].Name, "users should be sorted by name")
+
+	users, total, err = userDB.FindAll(UserFilter{Query: "CAROL"})
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, int64(1), total, "search should be case-insensitive")
+	assert.Equal(t, "Carol", users[0].Name)
+
+	_, total, err = userDB.FindAll(UserFilter{Query: "example.com"})
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, int64(5), total, "search should match email")
+
+	users, total, err = userDB.FindAll(UserFilter{Query: "_"})
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, int64(1), total, "wildcards should be matched literally")
+	assert.Equal(t, "Eve_Admin", users[0].Name)
+
+	_, total, err = userDB.FindAll(UserFilter{Role: entity.RoleAdmin})
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, int64(1), total, "role filter should be applied")
+
+	_, total, err = userDB.FindAll(UserFilter{Status: UserStatusVerified})
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, int64(3), total, "verified filter should be applied")
+
+	_, total, err = userDB.FindAll(UserFilter{Status: UserStatusUnverified})
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, int64(2), total, "unverified filter should be applied")
+
+	users, total, err = userDB.FindAll(UserFilter{Status: UserStatusLocked})
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, int64(1), total, "locked filter should be applied")
+	assert.Equal(t, "Dave", users[0].Name)
+
+	users, total, err = userDB.FindAll(UserFilter{Status: entity.UserStatusSuspended})
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, int64(1), total, "account status filter should be applied")
+	assert.Equal(t, "Bob", users[0].Name)
+
+	_, total, err = userDB.FindAll(UserFilter{Status: entity.UserStatusActive})
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, int64(4), total, "active filter should be applied")
+
+	users, total, err = userDB.FindAll(UserFilter{CreatedFrom: base.Add(24 * time.Hour), CreatedTo: base.Add(48 * time.Hour)})
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, int64(2), total, "creation date filter should be applied")
+	assert.Equal(t, "Bob", users[0].Name)
+}
+
+func TestUser_Erase(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.User{}, &entity.Session{}, &entity.APIKey{}, &entity.UserToken{},
+		&entity.RecoveryCode{}, &entity.AuditEntry{}, &entity.ComplianceRecord{})
+	defer cleanup()
+	if err := RegisterAuditCallbacks(db, "users"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := ContextWithAudit(tenantContext(context.Background()), AuditMetadata{Actor: "admin-1", RequestID: "req-1"})
+	userDB := NewUser(db).WithContext(ctx)
+	user, _ := entity.NewUser("John", "john@example.com", "test123")
+	assert.Nil(t, userDB.Create(user))
+	other, _ := entity.NewUser("Mary", "mary@example.com", "test123")
+	assert.Nil(t, userDB.Create(other))
+
+	session := entity.NewSession(user.ID, "curl", "10.0.0.1", time.Hour)
+	assert.Nil(t, db.Create(session).Error)
+	apiKey, _, _ := entity.NewAPIKey(user.ID, "ci", entity.Scopes{entity.ScopeProductsRead}, nil)
+	assert.Nil(t, db.Create(apiKey).Error)
+	otherSession := entity.NewSession(other.ID, "curl", "10.0.0.2", time.Hour)
+	assert.Nil(t, db.Create(otherSession).Error)
+	authored, _ := entity.NewAuditEntry(user.ID.String(), "req-0", entity.AuditOperationCreate, "products", "product-1", nil, map[string]string{"name": "Desk"})
+	authored.TenantID = testTenantID
+	assert.Nil(t, db.Create(authored).Error)
+
+	user.Anonymize(time.Now())
+	record, _ := entity.NewComplianceRecord(entity.ComplianceActionErasure, user.ID.String(), "admin-1", "req-1", "")
+	assert.Nil(t, userDB.Erase(user, record), "error should be nil")
+
+	erased, err := userDB.FindById(user.ID.String())
+	assert.Nil(t, err, "erased user should be kept")
+	assert.Equal(t, entity.ErasedUserName, erased.Name)
+	assert.True(t, erased.IsErased())
+
+	var count int64
+	db.Model(&entity.Session{}).Where("user_id = ?", user.ID).Count(&count)
+	assert.Equal(t, int64(0), count, "sessions should be deleted")
+	db.Model(&entity.APIKey{}).Where("user_id = ?", user.ID).Count(&count)
+	assert.Equal(t, int64(0), count, "api keys should be deleted")
+	db.Model(&entity.Session{}).Where("user_id = ?", other.ID).Count(&count)
+	assert.Equal(t, int64(1), count, "sessions of other users should be kept")
+
+	entries, err := NewAudit(db).WithContext(tenantContext(context.Background())).FindByEntity("users", user.ID.String())
+	assert.Nil(t, err, "error should be nil")
+	assert.Len(t, entries, 2, "creation and erasure should be audited")
+	for _, entry := range entries {
+		for _, field := range entity.UserPersonalFields {
+			if change, ok := entry.Changes[field]; ok {
+				assert.NotEqual(t, "John", change.Before, "personal data should be redacted")
+				assert.NotEqual(t, "john@example.com", change.After, "personal data should be redacted")
+			}
+		}
+	}
+	assert.Equal(t, entity.AuditRedacted, entries[0].Changes["email"].After)
+
+	entries, err = NewAudit(db).WithContext(tenantContext(context.Background())).FindAll(AuditFilter{Actor: user.ID.String()})
+	assert.Nil(t, err, "error should be nil")
+	assert.Len(t, entries, 1, "entries authored by the user should be kept")
+
+	records, err := NewCompliance(db).WithContext(tenantContext(context.Background())).FindAll(user.ID.String())
+	assert.Nil(t, err, "error should be nil")
+	assert.Len(t, records, 1, "erasure should be logged")
+}
+
+func TestUser_TenantIsolation(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.User{})
+	defer cleanup()
+
+	tenantA, tenantB := entitypkg.NewID(), entitypkg.NewID()
+	usersA := NewUser(db).WithContext(ContextWithTenant(context.Background(), tenantA))
+	usersB := NewUser(db).WithContext(ContextWithTenant(context.Background(), tenantB))
+
+	userA, _ := entity.NewUser("user A", "test@gmail.com", "test123")
+	userB, _ := entity.NewUser("user B", "test@gmail.com", "test123")
+	assert.Nil(t, usersA.Create(userA), "error should be nil")
+	assert.Nil(t, usersB.Create(userB), "the same email should be accepted in another tenant")
+
+	found, err := usersA.FindByEmail("test@gmail.com")
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, userA.ID, found.ID, "user of the tenant should be found")
+	found, err = usersB.FindByEmail("test@gmail.com")
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, userB.ID, found.ID, "user of the tenant should be found")
+
+	users, total, err := usersA.FindAll(UserFilter{})
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, int64(1), total, "only users of the tenant should be counted")
+	assert.Equal(t, userA.ID, users[0].ID)
+
+	_, err = usersB.FindById(userA.ID.String())
+	assert.NotNil(t, err, "user of another tenant should not be found")
+	assert.NotNil(t, usersB.Update(userA), "user of another tenant should not be updated")
+	assert.NotNil(t, usersB.Delete(userA.ID.String()), "user of another tenant should not be deleted")
+
+	found, err = usersB.FindByIdAcrossTenants(userA.ID.String())
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, tenantA, found.TenantID, "user should be found in any tenant")
+
+	duplicated, _ := entity.NewUser("user A2", "test@gmail.com", "test123")
+	assert.NotNil(t, usersA.Create(duplicated), "email should be unique in the tenant")
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"apis/internal/entity"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+var ErrUserTokenUsed = errors.New("token already used")
+
+type UserToken struct {
+	DB *gorm.DB
+}
+
+func NewUserToken(db *gorm.DB) *UserToken {
+	return &UserToken{DB: db}
+}
+
+func (ut *UserToken) WithContext(ctx context.Context) UserTokenInterface {
+	return NewUserToken(ut.DB.WithContext(ctx))
+}
+
+func (ut *UserToken) Create(token *entity.UserToken) error {
+	return ut.DB.Create(token).Error
+}
+
+func (ut *UserToken) FindByToken(purpose, token string) (*entity.UserToken, error) {
+	var userToken entity.UserToken
+	err := ut.DB.
+		Where("purpose = ? AND token_hash = ?", purpose, entity.HashUserToken(token)).
+		First(&userToken).Error
+	if err != nil {
+		return nil, err
+	}
+	return &userToken, nil
+}
+
+// Use marca o token como consumido. A condição em used_at garante o uso único
+// mesmo com requisições concorrentes.
+func (ut *UserToken) Use(token *entity.UserToken, now time.Time) error {
+	result := ut.DB.Model(&entity.UserToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserTokenUsed
+	}
+	token.UsedAt = &now
+	return nil
+}
+
+// DeleteByUser invalida os tokens anteriores do usuário para a finalidade.
+func (ut *UserToken) DeleteByUser(userID, purpose string) error {
+	return ut.DB.Where("user_id = ? AND purpose = ?", userID, purpose).Delete(&entity.UserToken{}).Error
+}
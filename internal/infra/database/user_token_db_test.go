@@ -0,0 +1,60 @@
+package database
+
+import (
+	"apis/internal/entity"
+	entitypkg "apis/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestUserToken_FindByToken(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.UserToken{})
+	defer cleanup()
+
+	tokenDB := NewUserToken(db)
+	token, plain, _ := entity.NewUserToken(entitypkg.NewID(), entity.TokenPurposePasswordReset, time.Hour)
+	assert.Nil(t, tokenDB.Create(token), "error should be nil")
+
+	found, err := tokenDB.FindByToken(entity.TokenPurposePasswordReset, plain)
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, token.ID, found.ID)
+
+	_, err = tokenDB.FindByToken(entity.TokenPurposePasswordReset, token.TokenHash)
+	assert.NotNil(t, err, "hash should not be accepted as token")
+
+	_, err = tokenDB.FindByToken("other", plain)
+	assert.NotNil(t, err, "token should be scoped by purpose")
+}
+
+func TestUserToken_Use(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.UserToken{})
+	defer cleanup()
+
+	tokenDB := NewUserToken(db)
+	token, plain, _ := entity.NewUserToken(entitypkg.NewID(), entity.TokenPurposePasswordReset, time.Hour)
+	assert.Nil(t, tokenDB.Create(token))
+
+	now := time.Now()
+	assert.Nil(t, tokenDB.Use(token, now), "first use should succeed")
+	assert.True(t, token.IsUsed())
+
+	stale, _ := tokenDB.FindByToken(entity.TokenPurposePasswordReset, plain)
+	assert.True(t, stale.IsUsed(), "used_at should be persisted")
+	stale.UsedAt = nil
+	assert.ErrorIs(t, tokenDB.Use(stale, now), ErrUserTokenUsed, "second use should fail")
+}
+
+func TestUserToken_DeleteByUser(t *testing.T) {
+	db, cleanup := initDBTest(t, &entity.UserToken{})
+	defer cleanup()
+
+	tokenDB := NewUserToken(db)
+	userID := entitypkg.NewID()
+	token, plain, _ := entity.NewUserToken(userID, entity.TokenPurposePasswordReset, time.Hour)
+	assert.Nil(t, tokenDB.Create(token))
+
+	assert.Nil(t, tokenDB.DeleteByUser(userID.String(), entity.TokenPurposePasswordReset))
+	_, err := tokenDB.FindByToken(entity.TokenPurposePasswordReset, plain)
+	assert.NotNil(t, err, "token should be deleted")
+}
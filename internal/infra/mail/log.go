@@ -0,0 +1,24 @@
+package mail
+
+import (
+	"apis/pkg/logger"
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// LogMailer escreve as mensagens no log em vez de enviá-las.
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (LogMailer) Send(ctx context.Context, message Message) error {
+	logger.FromContext(ctx).Info("mail sent",
+		zap.String("to", message.To),
+		zap.String("subject", message.Subject),
+		zap.String("body", message.Body),
+	)
+	return nil
+}
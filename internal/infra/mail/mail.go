@@ -0,0 +1,15 @@
+package mail
+
+import "context"
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer envia mensagens aos usuários. LogMailer atende o desenvolvimento e
+// SMTPMailer a produção.
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
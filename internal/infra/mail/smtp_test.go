@@ -0,0 +1,101 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSMTPMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// startFakeSMTP atende uma única sessão SMTP sem extensões e devolve a
+// mensagem recebida.
+func startFakeSMTP(t *testing.T) (string, string, <-chan fakeSMTPMessage) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	received := make(chan fakeSMTPMessage, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+		message := fakeSMTPMessage{}
+
+		reply("220 localhost fake smtp")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.TrimSpace(line)
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(command, "MAIL FROM:"):
+				message.from = strings.Trim(strings.TrimPrefix(command, "MAIL FROM:"), "<>")
+				reply("250 ok")
+			case strings.HasPrefix(command, "RCPT TO:"):
+				message.to = append(message.to, strings.Trim(strings.TrimPrefix(command, "RCPT TO:"), "<>"))
+				reply("250 ok")
+			case command == "DATA":
+				reply("354 end with .")
+				var data strings.Builder
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				message.data = data.String()
+				reply("250 queued")
+			case command == "QUIT":
+				reply("221 bye")
+				received <- message
+				return
+			default:
+				reply("502 not implemented")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	return host, port, received
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	host, port, received := startFakeSMTP(t)
+	mailer := NewSMTPMailer(host, port, "", "", "no-reply@example.com")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := mailer.Send(ctx, Message{To: "user@example.com", Subject: "Redefinição de senha", Body: "corpo"})
+	assert.Nil(t, err, "error should be nil")
+
+	message := <-received
+	assert.Equal(t, "no-reply@example.com", message.from)
+	assert.Equal(t, []string{"user@example.com"}, message.to)
+	assert.Contains(t, message.data, "To: user@example.com\r\n")
+	assert.Contains(t, message.data, "Subject: =?utf-8?q?Redefini=C3=A7=C3=A3o_de_senha?=\r\n", "subject should be encoded")
+	assert.True(t, strings.HasSuffix(message.data, "\r\n\r\ncorpo\r\n"), "body should follow the headers")
+}
@@ -0,0 +1,42 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"strings"
+	"text/template"
+)
+
+const (
+	TemplatePasswordReset     = "password_reset"
+	TemplateEmailVerification = "email_verification"
+)
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+var templates = template.Must(template.ParseFS(templateFiles, "templates/*.tmpl"))
+
+// TokenData alimenta os templates que enviam um link com token ao usuário.
+type TokenData struct {
+	Name      string
+	URL       string
+	ExpiresIn string
+}
+
+// Render monta a mensagem a partir dos blocos <nome>_subject e <nome>_body
+// do template.
+func Render(name, to string, data interface{}) (Message, error) {
+	var subject, body bytes.Buffer
+	if err := templates.ExecuteTemplate(&subject, name+"_subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := templates.ExecuteTemplate(&body, name+"_body", data); err != nil {
+		return Message{}, err
+	}
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    body.String(),
+	}, nil
+}
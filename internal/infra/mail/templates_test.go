@@ -0,0 +1,37 @@
+package mail
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderPasswordReset(t *testing.T) {
+	message, err := Render(TemplatePasswordReset, "user@example.com", TokenData{
+		Name:      "User",
+		URL:       "http://localhost:8000/reset?token=abc",
+		ExpiresIn: "1h0m0s",
+	})
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, "user@example.com", message.To)
+	assert.Equal(t, "Redefinição de senha", message.Subject)
+	assert.Contains(t, message.Body, "Olá, User.")
+	assert.Contains(t, message.Body, "http://localhost:8000/reset?token=abc")
+	assert.Contains(t, message.Body, "1h0m0s")
+}
+
+func TestRenderEmailVerification(t *testing.T) {
+	message, err := Render(TemplateEmailVerification, "user@example.com", TokenData{
+		Name:      "User",
+		URL:       "http://localhost:8000/users/auth/verify?token=abc",
+		ExpiresIn: "24h0m0s",
+	})
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, "Confirme seu e-mail", message.Subject)
+	assert.Contains(t, message.Body, "http://localhost:8000/users/auth/verify?token=abc")
+}
+
+func TestRenderUnknownTemplate(t *testing.T) {
+	_, err := Render("unknown", "user@example.com", nil)
+	assert.NotNil(t, err, "error should not be nil")
+}
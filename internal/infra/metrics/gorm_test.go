@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func observedMethods(t *testing.T) map[string]bool {
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	methods := map[string]bool{}
+	for _, family := range families {
+		if family.GetName() != "products_api_db_query_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			methods[labels["method"]+" "+labels["operation"]+" "+labels["table"]] = true
+		}
+	}
+	return methods
+}
+
+func TestGormPlugin(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, db.Use(GormPlugin{}), "plugin should be registered")
+	assert.Nil(t, db.AutoMigrate(&entity.User{}))
+
+	user, _ := entity.NewUser("test", "test@gmail.com", "test123")
+	userDB := database.NewUser(db).WithContext(database.ContextWithTenant(context.Background(), entitypkg.NewID()))
+	assert.Nil(t, userDB.Create(user))
+	_, err = userDB.FindByEmail(user.Email)
+	assert.Nil(t, err)
+
+	methods := observedMethods(t)
+	assert.True(t, methods["(*User).Create create users"], "create should be labeled with the repository method")
+	assert.True(t, methods["(*User).FindByEmail query users"], "query should be labeled with the repository method")
+}
@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type failureCount struct {
+	count   int
+	expires time.Time
+}
+
+// Backoff conta falhas por chave dentro de Window e calcula um atraso que
+// dobra a cada falha, começando em Base e limitado a Max.
+type Backoff struct {
+	Base   time.Duration
+	Max    time.Duration
+	Window time.Duration
+
+	mu        sync.Mutex
+	failures  map[string]*failureCount
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewBackoff(base, max, window time.Duration) *Backoff {
+	return &Backoff{
+		Base:     base,
+		Max:      max,
+		Window:   window,
+		failures: map[string]*failureCount{},
+		now:      time.Now,
+	}
+}
+
+// Fail registra uma falha em cada chave e devolve o atraso correspondente à
+// chave com mais falhas.
+func (b *Backoff) Fail(keys ...string) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	highest := 0
+	for _, key := range keys {
+		failure, ok := b.failures[key]
+		if !ok || !now.Before(failure.expires) {
+			failure = &failureCount{}
+			b.failures[key] = failure
+		}
+		failure.count++
+		failure.expires = now.Add(b.Window)
+		if failure.count > highest {
+			highest = failure.count
+		}
+	}
+	b.sweep(now)
+
+	return b.delay(highest)
+}
+
+// Reset descarta as falhas das chaves.
+func (b *Backoff) Reset(keys ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, key := range keys {
+		delete(b.failures, key)
+	}
+}
+
+func (b *Backoff) delay(failures int) time.Duration {
+	if failures == 0 || b.Base <= 0 {
+		return 0
+	}
+	delay := b.Base
+	for i := 1; i < failures; i++ {
+		delay *= 2
+		if b.Max > 0 && delay >= b.Max {
+			return b.Max
+		}
+	}
+	if b.Max > 0 && delay > b.Max {
+		return b.Max
+	}
+	return delay
+}
+
+func (b *Backoff) sweep(now time.Time) {
+	if now.Sub(b.lastSweep) < memorySweepInterval {
+		return
+	}
+	b.lastSweep = now
+	for key, failure := range b.failures {
+		if !now.Before(failure.expires) {
+			delete(b.failures, key)
+		}
+	}
+}
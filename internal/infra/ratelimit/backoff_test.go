@@ -0,0 +1,26 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoffFail(t *testing.T) {
+	now := time.Now()
+	backoff := NewBackoff(100*time.Millisecond, 350*time.Millisecond, time.Minute)
+	backoff.now = func() time.Time { return now }
+
+	assert.Equal(t, 100*time.Millisecond, backoff.Fail("email:a", "ip:1"))
+	assert.Equal(t, 200*time.Millisecond, backoff.Fail("email:a", "ip:1"))
+	assert.Equal(t, 350*time.Millisecond, backoff.Fail("email:a", "ip:1"), "delay should be capped")
+
+	assert.Equal(t, 350*time.Millisecond, backoff.Fail("email:b", "ip:1"), "delay should follow the key with most failures")
+
+	backoff.Reset("email:a", "ip:1")
+	assert.Equal(t, 200*time.Millisecond, backoff.Fail("email:b"), "reset should not affect other keys")
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, 100*time.Millisecond, backoff.Fail("email:b"), "failures should expire after the window")
+}
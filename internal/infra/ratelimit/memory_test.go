@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestStore(now *time.Time) *MemoryStore {
+	store := NewMemoryStore()
+	store.now = func() time.Time { return *now }
+	return store
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	now := time.Now()
+	store := newTestStore(&now)
+	limit := Limit{Requests: 2, Period: 10 * time.Second}
+
+	result, err := store.Take(context.Background(), "ip:1", limit)
+	assert.Nil(t, err, "error should be nil")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Limit)
+	assert.Equal(t, 1, result.Remaining)
+	assert.Equal(t, 5*time.Second, result.Reset)
+
+	result, _ = store.Take(context.Background(), "ip:1", limit)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	result, _ = store.Take(context.Background(), "ip:1", limit)
+	assert.False(t, result.Allowed, "bucket should be empty")
+	assert.Equal(t, 5*time.Second, result.RetryAfter)
+
+	result, _ = store.Take(context.Background(), "ip:2", limit)
+	assert.True(t, result.Allowed, "keys should have separate buckets")
+
+	now = now.Add(5 * time.Second)
+	result, _ = store.Take(context.Background(), "ip:1", limit)
+	assert.True(t, result.Allowed, "bucket should refill over time")
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	now := time.Now()
+	store := newTestStore(&now)
+	limit := Limit{Requests: 1, Period: time.Second}
+
+	_, _ = store.Take(context.Background(), "ip:1", limit)
+	now = now.Add(2 * memorySweepInterval)
+	_, _ = store.Take(context.Background(), "ip:2", limit)
+
+	assert.Len(t, store.buckets, 1, "full buckets should be discarded")
+}
@@ -0,0 +1,36 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Limit define um token bucket com capacidade Requests, reabastecido
+// integralmente a cada Period.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Store guarda os buckets. A implementação em memória atende uma instância;
+// para várias instâncias basta implementar Store sobre um armazenamento
+// compartilhado.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestGormPlugin(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, db.Use(GormPlugin{}), "plugin should be registered")
+	assert.Nil(t, db.AutoMigrate(&entity.User{}))
+
+	user, _ := entity.NewUser("test", "test@gmail.com", "test123")
+	userDB := database.NewUser(db).WithContext(database.ContextWithTenant(context.Background(), entitypkg.NewID()))
+	assert.Nil(t, userDB.Create(user))
+	assert.Empty(t, exporter.GetSpans(), "queries without a parent span should not be traced")
+
+	ctx, parent := Tracer().Start(context.Background(), "request")
+	_, err = userDB.WithContext(ctx).FindByEmail(user.Email)
+	assert.Nil(t, err)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	query := spans[0]
+	assert.Equal(t, "gorm.query", query.Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), query.Parent.SpanID(), "query span should be a child of the request span")
+
+	attributes := map[string]string{}
+	for _, attr := range query.Attributes {
+		attributes[string(attr.Key)] = attr.Value.Emit()
+	}
+	assert.Equal(t, "users", attributes["db.sql.table"])
+	assert.Equal(t, "(*User).FindByEmail", attributes["db.repository.method"])
+	assert.Contains(t, attributes["db.statement"], "WHERE email = ?")
+	assert.NotContains(t, attributes["db.statement"], user.Email, "statement should not include values")
+}
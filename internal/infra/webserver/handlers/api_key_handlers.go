@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"apis/internal/dto"
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/jwtauth"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type APIKeyHandler struct {
+	APIKeyDB database.APIKeyInterface
+}
+
+func NewAPIKeyHandler(db database.APIKeyInterface) *APIKeyHandler {
+	return &APIKeyHandler{
+		APIKeyDB: db,
+	}
+}
+
+// authorizeKeyManagement impede que uma chave de API crie ou gerencie outras chaves.
+func authorizeKeyManagement(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if isAPIKeyRequest(r) {
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(Error{Message: "api keys cannot be managed with an api key"})
+		return "", false
+	}
+	userID := currentUserID(r)
+	if userID == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(Error{Message: "unauthorized"})
+		return "", false
+	}
+	return userID, true
+}
+
+// CreateAPIKey godoc
+// @Summary Cria uma chave de API
+// @Description Cria uma chave de API para o usuário autenticado. A chave é exibida apenas nesta resposta.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateAPIKeyInput true "Dados da chave"
+// @Success 201 {object} dto.CreateAPIKeyOutput "Chave criada com sucesso"
+// @Failure 400 {object} Error "Dados inválidos"
+// @Failure 403 {object} Error "Acesso negado"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /users/me/api-keys [post]
+// @Security ApiKeyAuth
+func (ah *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizeKeyManagement(w, r)
+	if !ok {
+		return
+	}
+
+	input := dto.CreateAPIKeyInput{}
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+	if len(input.Scopes) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: "scopes are required"})
+		return
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: "expires_at must be in the future"})
+		return
+	}
+
+	// A chave não pode receber escopos que o próprio token não possui.
+	_, claims, _ := jwtauth.FromContext(r.Context())
+	tokenScope, _ := claims["scope"].(string)
+	if _, err = entity.ParseScopes(tokenScope).Grant(entity.Scopes(input.Scopes)); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	id, err := entitypkg.ParseID(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(Error{Message: "unauthorized"})
+		return
+	}
+
+	apiKey, key, err := entity.NewAPIKey(id, input.Name, entity.Scopes(input.Scopes), input.ExpiresAt)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	err = ah.APIKeyDB.WithContext(r.Context()).Create(apiKey)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(dto.CreateAPIKeyOutput{
+		ID:        apiKey.ID.String(),
+		Name:      apiKey.Name,
+		Prefix:    apiKey.Prefix,
+		Key:       key,
+		Scopes:    apiKey.Scopes,
+		ExpiresAt: apiKey.ExpiresAt,
+		CreatedAt: apiKey.CreatedAt,
+	})
+}
+
+// GetAPIKeys godoc
+// @Summary Lista as chaves de API
+// @Description Lista as chaves de API do usuário autenticado
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Success 200 {array} entity.APIKey "Chaves encontradas com sucesso"
+// @Failure 403 {object} Error "Acesso negado"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /users/me/api-keys [get]
+// @Security ApiKeyAuth
+func (ah *APIKeyHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizeKeyManagement(w, r)
+	if !ok {
+		return
+	}
+
+	apiKeys, err := ah.APIKeyDB.WithContext(r.Context()).FindByUser(userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(apiKeys)
+}
+
+// GetAPIKey godoc
+// @Summary Busca uma chave de API
+// @Description Busca uma chave de API do usuário autenticado
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param keyID path string true "ID da chave"
+// @Success 200 {object} entity.APIKey "Chave encontrada com sucesso"
+// @Failure 403 {object} Error "Acesso negado"
+// @Failure 404 {object} Error "Chave não encontrada"
+// @Router /users/me/api-keys/{keyID} [get]
+// @Security ApiKeyAuth
+func (ah *APIKeyHandler) GetAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizeKeyManagement(w, r)
+	if !ok {
+		return
+	}
+
+	apiKey, err := ah.APIKeyDB.WithContext(r.Context()).FindByID(userID, chi.URLParam(r, "keyID"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(Error{Message: "api key not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(apiKey)
+}
+
+// UpdateAPIKey godoc
+// @Summary Renomeia uma chave de API
+// @Description Altera o nome de uma chave de API do usuário autenticado
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param keyID path string true "ID da chave"
+// @Param request body dto.UpdateAPIKeyInput true "Novo nome da chave"
+// @Success 200 {object} entity.APIKey "Chave atualizada com sucesso"
+// @Failure 400 {object} Error "Dados inválidos"
+// @Failure 403 {object} Error "Acesso negado"
+// @Failure 404 {object} Error "Chave não encontrada"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /users/me/api-keys/{keyID} [patch]
+// @Security ApiKeyAuth
+func (ah *APIKeyHandler) UpdateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizeKeyManagement(w, r)
+	if !ok {
+		return
+	}
+
+	input := dto.UpdateAPIKeyInput{}
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+	if strings.TrimSpace(input.Name) == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: entity.ErrAPIKeyNameIsRequired.Error()})
+		return
+	}
+
+	apiKeyDB := ah.APIKeyDB.WithContext(r.Context())
+	apiKey, err := apiKeyDB.FindByID(userID, chi.URLParam(r, "keyID"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(Error{Message: "api key not found"})
+		return
+	}
+
+	apiKey.Name = input.Name
+	if err = apiKeyDB.Update(apiKey); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(apiKey)
+}
+
+// DeleteAPIKey godoc
+// @Summary Revoga uma chave de API
+// @Description Remove uma chave de API do usuário autenticado. Requisições com a chave passam a ser recusadas.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param keyID path string true "ID da chave"
+// @Success 204 "Chave removida com sucesso"
+// @Failure 403 {object} Error "Acesso negado"
+// @Failure 404 {object} Error "Chave não encontrada"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /users/me/api-keys/{keyID} [delete]
+// @Security ApiKeyAuth
+func (ah *APIKeyHandler) DeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := authorizeKeyManagement(w, r)
+	if !ok {
+		return
+	}
+
+	apiKeyDB := ah.APIKeyDB.WithContext(r.Context())
+	apiKey, err := apiKeyDB.FindByID(userID, chi.URLParam(r, "keyID"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(Error{Message: "api key not found"})
+		return
+	}
+
+	if err = apiKeyDB.Delete(userID, apiKey.ID.String()); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
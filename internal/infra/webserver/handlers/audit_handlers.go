@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"apis/internal/infra/database"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type AuditHandler struct {
+	AuditDB database.AuditInterface
+}
+
+func NewAuditHandler(db database.AuditInterface) *AuditHandler {
+	return &AuditHandler{
+		AuditDB: db,
+	}
+}
+
+// GetAudit godoc
+// @Summary Busca entradas de auditoria
+// @Description Busca entradas de auditoria de produtos e usuários, da mais recente para a mais antiga. Restrito a administradores.
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Param actor query string false "ID do usuário que executou a operação"
+// @Param entity query string false "Tipo da entidade" Enums(products, users)
+// @Param entity_id query string false "ID da entidade"
+// @Param from query string false "Data inicial (RFC 3339)"
+// @Param to query string false "Data final (RFC 3339)"
+// @Param page query string false "Número da página"
+// @Param limit query string false "Número de itens por página"
+// @Success 200 {array} entity.AuditEntry "Entradas encontradas com sucesso"
+// @Failure 400 {object} Error "Dados inválidos"
+// @Failure 403 {object} Error "Acesso negado"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /audit [get]
+// @Security ApiKeyAuth
+func (ah *AuditHandler) GetAudit(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := database.AuditFilter{
+		Actor:      query.Get("actor"),
+		EntityType: query.Get("entity"),
+		EntityID:   query.Get("entity_id"),
+	}
+
+	var err error
+	if from := query.Get("from"); from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(Error{Message: "invalid from"})
+			return
+		}
+	}
+	if to := query.Get("to"); to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(Error{Message: "invalid to"})
+			return
+		}
+	}
+
+	filter.Page, _ = strconv.Atoi(query.Get("page"))
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+
+	entries, err := ah.AuditDB.WithContext(r.Context()).FindAll(filter)
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(entries)
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+}
@@ -2,4 +2,10 @@ package handlers
 
 type Error struct {
 	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
 }
+
+// Códigos que permitem ao cliente distinguir erros com o mesmo status HTTP.
+const (
+	ErrorCodeAccountSuspended = "account_suspended"
+)
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"apis/internal/dto"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	HealthStatusOK   = "ok"
+	HealthStatusFail = "fail"
+
+	DefaultHealthCheckTimeout = 2 * time.Second
+)
+
+// HealthCheck é uma dependência verificada pelo endpoint de prontidão.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+type HealthHandler struct {
+	Checks   []HealthCheck
+	Timeout  time.Duration
+	draining atomic.Bool
+}
+
+func NewHealthHandler(checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{
+		Checks:  checks,
+		Timeout: DefaultHealthCheckTimeout,
+	}
+}
+
+// SetDraining faz a prontidão falhar durante o encerramento do servidor, para
+// que o orquestrador pare de enviar novas requisições.
+func (hh *HealthHandler) SetDraining() {
+	hh.draining.Store(true)
+}
+
+// Healthz godoc
+// @Summary Verifica se o processo está vivo
+// @Description Responde sempre 200 enquanto o processo estiver em execução, sem verificar dependências
+// @Tags health
+// @Produce json
+// @Success 200 {object} dto.HealthOutput "Processo vivo"
+// @Router /healthz [get]
+func (hh *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(dto.HealthOutput{Status: HealthStatusOK})
+}
+
+// Readyz godoc
+// @Summary Verifica se a API está pronta para receber tráfego
+// @Description Verifica a conexão com o banco, a versão das migrações e a chave JWT, informando a duração de cada verificação. Falha enquanto o servidor está sendo encerrado.
+// @Tags health
+// @Produce json
+// @Success 200 {object} dto.HealthOutput "Todas as verificações passaram"
+// @Failure 503 {object} dto.HealthOutput "Alguma verificação falhou"
+// @Router /readyz [get]
+func (hh *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	output := dto.HealthOutput{Status: HealthStatusOK}
+
+	if hh.draining.Load() {
+		output.Status = HealthStatusFail
+		output.Checks = append(output.Checks, dto.HealthCheckOutput{
+			Name:   "shutdown",
+			Status: HealthStatusFail,
+			Error:  "server is shutting down",
+		})
+	}
+
+	for _, check := range hh.Checks {
+		result := hh.run(r.Context(), check)
+		if result.Status != HealthStatusOK {
+			output.Status = HealthStatusFail
+		}
+		output.Checks = append(output.Checks, result)
+	}
+
+	status := http.StatusOK
+	if output.Status != HealthStatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(output)
+}
+
+func (hh *HealthHandler) run(ctx context.Context, check HealthCheck) dto.HealthCheckOutput {
+	ctx, cancel := context.WithTimeout(ctx, hh.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Check(ctx)
+	result := dto.HealthCheckOutput{
+		Name:       check.Name,
+		Status:     HealthStatusOK,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = HealthStatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"apis/internal/dto"
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	"apis/internal/infra/metrics"
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"mime"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypePassword          = "password"
+
+	// Códigos de erro da seção 5.2 da RFC 6749.
+	OAuthErrorInvalidRequest       = "invalid_request"
+	OAuthErrorInvalidClient        = "invalid_client"
+	OAuthErrorInvalidGrant         = "invalid_grant"
+	OAuthErrorUnsupportedGrantType = "unsupported_grant_type"
+	OAuthErrorInvalidScope         = "invalid_scope"
+)
+
+type OAuthHandler struct {
+	ClientDB database.OAuthClientInterface
+	Users    *UserHandler
+}
+
+func NewOAuthHandler(clientDB database.OAuthClientInterface, users *UserHandler) *OAuthHandler {
+	return &OAuthHandler{
+		ClientDB: clientDB,
+		Users:    users,
+	}
+}
+
+// Token godoc
+// @Summary Emite um token OAuth2
+// @Description Emite um token de acesso conforme a RFC 6749 com os grants client_credentials e password. O cliente se autentica por HTTP Basic ou pelos campos client_id e client_secret.
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "Tipo de concessão" Enums(client_credentials, password)
+// @Param scope formData string false "Escopos pedidos, separados por espaço"
+// @Param username formData string false "E-mail do usuário (grant password)"
+// @Param password formData string false "Senha do usuário (grant password)"
+// @Param client_id formData string false "ID do cliente, quando não enviado por HTTP Basic"
+// @Param client_secret formData string false "Segredo do cliente, quando não enviado por HTTP Basic"
+// @Success 200 {object} dto.OAuthTokenOutput "Token emitido com sucesso"
+// @Failure 400 {object} dto.OAuthErrorOutput "Pedido inválido"
+// @Failure 401 {object} dto.OAuthErrorOutput "Cliente inválido"
+// @Failure 429 {object} Error "Limite de requisições excedido"
+// @Router /oauth/token [post]
+func (oh *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/x-www-form-urlencoded" {
+		writeOAuthError(w, http.StatusBadRequest, OAuthErrorInvalidRequest, "content type must be application/x-www-form-urlencoded")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, OAuthErrorInvalidRequest, err.Error())
+		return
+	}
+	// Os parâmetros não podem ser repetidos (RFC 6749, seção 3.2).
+	for _, param := range []string{"grant_type", "scope", "username", "password", "client_id", "client_secret"} {
+		if len(r.PostForm[param]) > 1 {
+			writeOAuthError(w, http.StatusBadRequest, OAuthErrorInvalidRequest, param+" must not be repeated")
+			return
+		}
+	}
+
+	client, ok := oh.authenticateClient(w, r)
+	if !ok {
+		return
+	}
+
+	// O cliente pertence a um tenant, que vale para o token e para o login do
+	// grant password.
+	r = r.WithContext(database.ContextWithTenant(r.Context(), client.TenantID))
+	requested := entity.ParseScopes(r.PostForm.Get("scope"))
+	jwt, jwtExpiresIn := oh.Users.GetAuth(r)
+	var claims map[string]interface{}
+	var scopes entity.Scopes
+
+	switch r.PostForm.Get("grant_type") {
+	case GrantTypeClientCredentials:
+		var err error
+		if scopes, err = client.Scopes.Grant(requested); err != nil {
+			writeOAuthError(w, http.StatusBadRequest, OAuthErrorInvalidScope, "requested scope is not allowed for this client")
+			return
+		}
+		now := time.Now()
+		claims = map[string]interface{}{
+			"sub":                client.ClientID,
+			"iat":                now.Unix(),
+			"exp":                now.Add(time.Second * time.Duration(jwtExpiresIn)).Unix(),
+			entity.TenantIDClaim: client.TenantID.String(),
+		}
+	case GrantTypePassword:
+		username, password := r.PostForm.Get("username"), r.PostForm.Get("password")
+		if username == "" || password == "" {
+			writeOAuthError(w, http.StatusBadRequest, OAuthErrorInvalidRequest, "username and password are required")
+			return
+		}
+		user, err := oh.Users.checkCredentials(r, username, password)
+		if errors.Is(err, errEmailNotVerified) {
+			writeOAuthError(w, http.StatusBadRequest, OAuthErrorInvalidGrant, "email not verified")
+			return
+		}
+		if err != nil {
+			writeOAuthError(w, http.StatusBadRequest, OAuthErrorInvalidGrant, "invalid credentials")
+			return
+		}
+		// O grant password não tem como receber o segundo fator.
+		if user.TOTPEnabled {
+			writeOAuthError(w, http.StatusBadRequest, OAuthErrorInvalidGrant, "multi-factor authentication required")
+			return
+		}
+		// O token recebe apenas escopos permitidos ao cliente e ao usuário.
+		if scopes, err = client.Scopes.Intersect(user.AllowedScopes()).Grant(requested); err != nil {
+			writeOAuthError(w, http.StatusBadRequest, OAuthErrorInvalidScope, "requested scope is not allowed for this client or user")
+			return
+		}
+		if claims, err = oh.Users.accessTokenClaims(r, user, scopes); err != nil {
+			writeOAuthError(w, http.StatusInternalServerError, "server_error", err.Error())
+			return
+		}
+		metrics.LoginAttemptsTotal.WithLabelValues(metrics.LoginSuccess).Inc()
+	case "":
+		writeOAuthError(w, http.StatusBadRequest, OAuthErrorInvalidRequest, "grant_type is required")
+		return
+	default:
+		writeOAuthError(w, http.StatusBadRequest, OAuthErrorUnsupportedGrantType, "grant type is not supported")
+		return
+	}
+
+	claims["client_id"] = client.ClientID
+	claims["scope"] = scopes.String()
+	_, tokenString, err := jwt.Encode(claims)
+	if err != nil {
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(dto.OAuthTokenOutput{
+		AccessToken: tokenString,
+		TokenType:   "Bearer",
+		ExpiresIn:   jwtExpiresIn,
+		Scope:       scopes.String(),
+	})
+}
+
+// authenticateClient aceita as credenciais do cliente por HTTP Basic ou no
+// corpo do pedido, mas não pelos dois ao mesmo tempo.
+func (oh *OAuthHandler) authenticateClient(w http.ResponseWriter, r *http.Request) (*entity.OAuthClient, bool) {
+	clientID, secret, basic := r.BasicAuth()
+	if basic {
+		if r.PostForm.Get("client_secret") != "" {
+			writeOAuthError(w, http.StatusBadRequest, OAuthErrorInvalidRequest, "client credentials must be sent only once")
+			return nil, false
+		}
+		// No HTTP Basic as credenciais são codificadas como formulário (RFC 6749, seção 2.3.1).
+		var errID, errSecret error
+		clientID, errID = url.QueryUnescape(clientID)
+		secret, errSecret = url.QueryUnescape(secret)
+		if errID != nil || errSecret != nil {
+			clientID, secret = "", ""
+		}
+	} else {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	if clientID != "" && secret != "" {
+		client, err := oh.ClientDB.WithContext(r.Context()).FindByClientIDAcrossTenants(clientID)
+		if err == nil && client.CheckSecret(secret) {
+			return client, true
+		}
+	}
+
+	if basic {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	writeOAuthError(w, http.StatusUnauthorized, OAuthErrorInvalidClient, "client authentication failed")
+	return nil, false
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(dto.OAuthErrorOutput{Error: code, ErrorDescription: description})
+}
+
+// CreateClient godoc
+// @Summary Registra um cliente OAuth2
+// @Description Registra um cliente OAuth2. O client_secret é exibido apenas nesta resposta. Restrito a administradores.
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateOAuthClientInput true "Dados do cliente"
+// @Success 201 {object} dto.CreateOAuthClientOutput "Cliente criado com sucesso"
+// @Failure 400 {object} Error "Dados inválidos"
+// @Failure 403 {object} Error "Acesso negado"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /oauth/clients [post]
+// @Security ApiKeyAuth
+func (oh *OAuthHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
+	input := dto.CreateOAuthClientInput{}
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+	if len(input.Scopes) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: "scopes are required"})
+		return
+	}
+
+	client, secret, err := entity.NewOAuthClient(input.Name, entity.Scopes(input.Scopes))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	err = oh.ClientDB.WithContext(r.Context()).Create(client)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(dto.CreateOAuthClientOutput{
+		ID:           client.ID.String(),
+		ClientID:     client.ClientID,
+		ClientSecret: secret,
+		Name:         client.Name,
+		Scopes:       client.Scopes,
+		CreatedAt:    client.CreatedAt,
+	})
+}
+
+// GetClients godoc
+// @Summary Lista os clientes OAuth2
+// @Description Lista os clientes OAuth2 registrados. Restrito a administradores.
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Success 200 {array} entity.OAuthClient "Clientes encontrados com sucesso"
+// @Failure 403 {object} Error "Acesso negado"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /oauth/clients [get]
+// @Security ApiKeyAuth
+func (oh *OAuthHandler) GetClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := oh.ClientDB.WithContext(r.Context()).FindAll()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(clients)
+}
+
+// DeleteClient godoc
+// @Summary Remove um cliente OAuth2
+// @Description Remove um cliente OAuth2. Novos tokens deixam de ser emitidos para ele. Restrito a administradores.
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do cliente"
+// @Success 204 "Cliente removido com sucesso"
+// @Failure 403 {object} Error "Acesso negado"
+// @Failure 404 {object} Error "Cliente não encontrado"
+// @Router /oauth/clients/{id} [delete]
+// @Security ApiKeyAuth
+func (oh *OAuthHandler) DeleteClient(w http.ResponseWriter, r *http.Request) {
+	err := oh.ClientDB.WithContext(r.Context()).Delete(chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(Error{Message: "client not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"apis/internal/dto"
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"apis/pkg/logger"
+	"archive/zip"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	ExportFormatJSON = "json"
+	ExportFormatZIP  = "zip"
+)
+
+// PrivacyHandler atende os pedidos dos titulares de dados: exportação e
+// apagamento. Cada atendimento fica registrado no log de conformidade.
+type PrivacyHandler struct {
+	UserDB       database.UserInterface
+	SessionDB    database.SessionInterface
+	APIKeyDB     database.APIKeyInterface
+	AuditDB      database.AuditInterface
+	ComplianceDB database.ComplianceInterface
+}
+
+func NewPrivacyHandler(userDB database.UserInterface, sessionDB database.SessionInterface, apiKeyDB database.APIKeyInterface, auditDB database.AuditInterface, complianceDB database.ComplianceInterface) *PrivacyHandler {
+	return &PrivacyHandler{
+		UserDB:       userDB,
+		SessionDB:    sessionDB,
+		APIKeyDB:     apiKeyDB,
+		AuditDB:      auditDB,
+		ComplianceDB: complianceDB,
+	}
+}
+
+// ExportUser godoc
+// @Summary Exporta os dados de um usuário
+// @Description Exporta o cadastro, as sessões, as chaves de API e as entradas de auditoria de autoria do usuário. Por padrão devolve um ZIP com um arquivo JSON por seção. Permitido ao próprio usuário e a administradores.
+// @Tags users
+// @Produce json,application/zip
+// @Param id path string true "ID do usuário"
+// @Param format query string false "Formato da exportação" Enums(zip, json) default(zip)
+// @Success 200 {object} dto.UserExportOutput "Dados exportados com sucesso"
+// @Failure 400 {object} Error "Dados inválidos"
+// @Failure 403 {object} Error "Acesso negado"
+// @Failure 404 {object} Error "Usuário não encontrado"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /users/{id}/export [get]
+// @Security ApiKeyAuth
+// @Security APIKeyHeader
+func (ph *PrivacyHandler) ExportUser(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entitypkg.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: "id is required"})
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = ExportFormatZIP
+	}
+	if format != ExportFormatZIP && format != ExportFormatJSON {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: "invalid format"})
+		return
+	}
+	if !authorizeUser(w, r, id) {
+		return
+	}
+
+	export, err := ph.collectUserData(r, id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(Error{Message: "user not found"})
+		return
+	}
+
+	if !ph.recordCompliance(w, r, entity.ComplianceActionExport, id, format) {
+		return
+	}
+
+	if format == ExportFormatJSON {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="user-`+id+`.json"`)
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(export)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="user-`+id+`.zip"`)
+	w.WriteHeader(http.StatusOK)
+	if err := writeExportZIP(w, export); err != nil {
+		logger.FromContext(r.Context()).Error("failed to write user export", zap.Error(err))
+	}
+}
+
+func (ph *PrivacyHandler) collectUserData(r *http.Request, id string) (*dto.UserExportOutput, error) {
+	user, err := ph.UserDB.WithContext(r.Context()).FindById(id)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := ph.SessionDB.WithContext(r.Context()).FindByUser(id)
+	if err != nil {
+		return nil, err
+	}
+	apiKeys, err := ph.APIKeyDB.WithContext(r.Context()).FindByUser(id)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := ph.AuditDB.WithContext(r.Context()).FindAll(database.AuditFilter{Actor: id})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.UserExportOutput{
+		ExportedAt:   time.Now(),
+		Profile:      user,
+		Sessions:     sessions,
+		APIKeys:      apiKeys,
+		AuditEntries: entries,
+	}, nil
+}
+
+func writeExportZIP(w http.ResponseWriter, export *dto.UserExportOutput) error {
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"sessions.json", export.Sessions},
+		{"api_keys.json", export.APIKeys},
+		{"audit_entries.json", export.AuditEntries},
+	}
+	for _, file := range files {
+		f, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: export.ExportedAt})
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// EraseUser godoc
+// @Summary Apaga os dados pessoais de um usuário
+// @Description Anonimiza o cadastro do usuário e remove suas sessões, chaves de API e tokens. Os registros criados por ele são mantidos com o mesmo ID, e os dados pessoais são retirados do histórico de auditoria. A conta fica desativada. Restrito a administradores.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do usuário"
+// @Param request body dto.EraseUserInput false "Motivo ou referência do pedido"
+// @Success 200 {object} entity.User "Dados apagados com sucesso"
+// @Failure 400 {object} Error "Dados inválidos"
+// @Failure 403 {object} Error "Acesso negado"
+// @Failure 404 {object} Error "Usuário não encontrado"
+// @Failure 409 {object} Error "Dados já apagados"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /users/{id}/erase [post]
+// @Security ApiKeyAuth
+// @Security APIKeyHeader
+func (ph *PrivacyHandler) EraseUser(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, err := entitypkg.ParseID(id); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: "id is required"})
+		return
+	}
+	if id == currentUserID(r) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: "cannot erase your own account"})
+		return
+	}
+
+	// O corpo é opcional.
+	input := dto.EraseUserInput{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+			return
+		}
+	}
+
+	userDB := ph.UserDB.WithContext(r.Context())
+	user, err := userDB.FindById(id)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(Error{Message: "user not found"})
+		return
+	}
+	if user.IsErased() {
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(Error{Message: "user already erased"})
+		return
+	}
+
+	metadata := database.AuditFromContext(r.Context())
+	record, err := entity.NewComplianceRecord(entity.ComplianceActionErasure, id, metadata.Actor, metadata.RequestID, strings.TrimSpace(input.Reason))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	user.Anonymize(time.Now())
+	if err = userDB.Erase(user, record); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+	logger.FromContext(r.Context()).Info("user personal data erased", zap.String("subject_id", id))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(user)
+}
+
+// GetComplianceLog godoc
+// @Summary Lista o log de conformidade
+// @Description Lista as exportações e os apagamentos de dados pessoais, do mais antigo para o mais recente. Restrito a administradores.
+// @Tags compliance
+// @Produce json
+// @Param subject query string false "ID do titular dos dados"
+// @Success 200 {array} entity.ComplianceRecord "Registros encontrados com sucesso"
+// @Failure 403 {object} Error "Acesso negado"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /compliance [get]
+// @Security ApiKeyAuth
+func (ph *PrivacyHandler) GetComplianceLog(w http.ResponseWriter, r *http.Request) {
+	records, err := ph.ComplianceDB.WithContext(r.Context()).FindAll(r.URL.Query().Get("subject"))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(records)
+}
+
+// recordCompliance grava o atendimento antes de responder, para que nenhum
+// dado seja entregue sem registro.
+func (ph *PrivacyHandler) recordCompliance(w http.ResponseWriter, r *http.Request, action, subjectID, details string) bool {
+	metadata := database.AuditFromContext(r.Context())
+	record, err := entity.NewComplianceRecord(action, subjectID, metadata.Actor, metadata.RequestID, details)
+	if err == nil {
+		err = ph.ComplianceDB.WithContext(r.Context()).Create(record)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return false
+	}
+	return true
+}
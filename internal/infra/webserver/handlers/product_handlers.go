@@ -6,19 +6,65 @@ import (
 	"apis/internal/infra/database"
 	entitypkg "apis/pkg/entity"
 	"encoding/json"
+	"errors"
 	"github.com/go-chi/chi/v5"
 	"net/http"
 	"strconv"
+	"time"
+)
+
+const (
+	BulkModeAtomic          = "atomic"
+	BulkModeBestEffort      = "best_effort"
+	DefaultBulkMaxBatchSize = 500
+	bulkInsertBatchSize     = 100
+	productsTable           = "products"
+)
+
+var (
+	ErrBulkOperationsRequired = errors.New("operations are required")
+	ErrBulkBatchTooLarge      = errors.New("too many operations in batch")
+	ErrBulkInvalidMode        = errors.New("invalid mode")
+	ErrBulkInvalidOperation   = errors.New("invalid operation")
+	errBulkRolledBack         = errors.New("bulk operation rolled back")
 )
 
 type ProductHandler struct {
-	ProductDB database.ProductInterface
+	ProductDB        database.ProductInterface
+	AuditDB          database.AuditInterface
+	PriceDB          database.ProductPriceInterface
+	BulkMaxBatchSize int
 }
 
-func NewProductHandler(db database.ProductInterface) *ProductHandler {
+func NewProductHandler(db database.ProductInterface, auditDB database.AuditInterface, priceDB database.ProductPriceInterface) *ProductHandler {
 	return &ProductHandler{
-		ProductDB: db,
+		ProductDB:        db,
+		AuditDB:          auditDB,
+		PriceDB:          priceDB,
+		BulkMaxBatchSize: DefaultBulkMaxBatchSize,
+	}
+}
+
+// setLowestPrices preenche o menor preço dos últimos 30 dias de cada produto.
+func setLowestPrices(r *http.Request, priceDB database.ProductPriceInterface, products ...*entity.Product) error {
+	ids := make([]string, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID.String())
+	}
+
+	lowest, err := priceDB.WithContext(r.Context()).FindLowestSince(ids, time.Now().Add(-entity.LowestPriceWindow))
+	if err != nil {
+		return err
 	}
+
+	for _, product := range products {
+		if price, ok := lowest[product.ID.String()]; ok {
+			product.LowestPrice30d = price
+		} else {
+			product.LowestPrice30d = product.Price
+		}
+	}
+	return nil
 }
 
 // Create Product godoc
@@ -28,11 +74,16 @@ func NewProductHandler(db database.ProductInterface) *ProductHandler {
 // @Accept json
 // @Produce json
 // @Param request body dto.CreateProductInput true "Dados do produto"
+// @Param Idempotency-Key header string false "Chave de idempotência da requisição"
 // @Success 201 {object} dto.CreateProductOutput "Produto criado com sucesso"
 // @Failure 400 {object} Error "Dados inválidos"
+// @Failure 403 {object} Error "Escopo insuficiente"
+// @Failure 409 {object} Error "Requisição com a mesma chave em andamento"
+// @Failure 422 {object} Error "Chave de idempotência reutilizada com outro corpo"
 // @Failure 500 {object} Error "Erro interno"
 // @Router /products [post]
 // @Security ApiKeyAuth
+// @Security APIKeyHeader
 func (ph *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 	input := dto.CreateProductInput{}
 	err := json.NewDecoder(r.Body).Decode(&input)
@@ -50,7 +101,7 @@ func (ph *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = ph.ProductDB.Create(p)
+	err = ph.ProductDB.WithContext(r.Context()).Create(p)
 
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -58,12 +109,19 @@ func (ph *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err = setLowestPrices(r, ph.PriceDB, p); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
 	w.WriteHeader(http.StatusCreated)
 	err = json.NewEncoder(w).Encode(dto.CreateProductOutput{
-		ID:          p.ID.String(),
-		Name:        p.Name,
-		Description: p.Description,
-		Price:       p.Price,
+		ID:             p.ID.String(),
+		Name:           p.Name,
+		Description:    p.Description,
+		Price:          p.Price,
+		LowestPrice30d: p.LowestPrice30d,
 	})
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -80,10 +138,12 @@ func (ph *ProductHandler) Create(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param id path string true "ID do produto" Format(uuid)
 // @Success 200 {object} entity.Product "Produto encontrado com sucesso"
+// @Failure 403 {object} Error "Escopo insuficiente"
 // @Failure 404 {object} Error "Produto não encontrado"
 // @Failure 500 {object} Error "Erro interno"
 // @Router /products/{id} [get]
 // @Security ApiKeyAuth
+// @Security APIKeyHeader
 func (ph *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
@@ -93,7 +153,7 @@ func (ph *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	product, err := ph.ProductDB.FindById(id)
+	product, err := ph.ProductDB.WithContext(r.Context()).FindById(id)
 
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
@@ -101,6 +161,12 @@ func (ph *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err = setLowestPrices(r, ph.PriceDB, product); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
 	w.WriteHeader(http.StatusOK)
 	err = json.NewEncoder(w).Encode(product)
 
@@ -121,10 +187,12 @@ func (ph *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 // @Param request body dto.UpdateProductInput true "Dados do produto"
 // @Success 202 {object} dto.UpdateProductOutput "Produto atualizado com sucesso"
 // @Failure 400 {object} Error "Dados inválidos"
+// @Failure 403 {object} Error "Escopo insuficiente"
 // @Failure 404 {object} Error "Produto não encontrado"
 // @Failure 500 {object} Error "Erro interno"
 // @Router /products/{id} [put]
 // @Security ApiKeyAuth
+// @Security APIKeyHeader
 func (ph *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
@@ -142,7 +210,8 @@ func (ph *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	product, err := ph.ProductDB.FindById(id)
+	productDB := ph.ProductDB.WithContext(r.Context())
+	product, err := productDB.FindById(id)
 
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
@@ -154,7 +223,7 @@ func (ph *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request)
 	product.Description = input.Description
 	product.Price = input.Price
 
-	err = ph.ProductDB.Update(product)
+	err = productDB.Update(product)
 
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -162,12 +231,19 @@ func (ph *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if err = setLowestPrices(r, ph.PriceDB, product); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
 	w.WriteHeader(http.StatusAccepted)
 	err = json.NewEncoder(w).Encode(dto.UpdateProductOutput{
-		ID:          product.ID.String(),
-		Name:        product.Name,
-		Description: product.Description,
-		Price:       product.Price,
+		ID:             product.ID.String(),
+		Name:           product.Name,
+		Description:    product.Description,
+		Price:          product.Price,
+		LowestPrice30d: product.LowestPrice30d,
 	})
 
 	if err != nil {
@@ -186,10 +262,12 @@ func (ph *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request)
 // @Param id path string true "ID do produto" Format(uuid)
 // @Success 204 "Produto deletado com sucesso"
 // @Failure 400 {object} Error "Dados inválidos"
+// @Failure 403 {object} Error "Escopo insuficiente"
 // @Failure 404 {object} Error "Produto não encontrado"
 // @Failure 500 {object} Error "Erro interno"
 // @Router /products/{id} [delete]
 // @Security ApiKeyAuth
+// @Security APIKeyHeader
 func (ph *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
@@ -199,7 +277,7 @@ func (ph *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err := ph.ProductDB.Delete(id)
+	err := ph.ProductDB.WithContext(r.Context()).Delete(id)
 
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
@@ -210,6 +288,102 @@ func (ph *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// GetProductHistory godoc
+// @Summary Histórico de alterações de um produto
+// @Description Lista as entradas de auditoria de um produto, da mais antiga para a mais recente
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do produto" Format(uuid)
+// @Success 200 {array} entity.AuditEntry "Histórico encontrado com sucesso"
+// @Failure 400 {object} Error "Dados inválidos"
+// @Failure 403 {object} Error "Escopo insuficiente"
+// @Failure 404 {object} Error "Histórico não encontrado"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /products/{id}/history [get]
+// @Security ApiKeyAuth
+// @Security APIKeyHeader
+func (ph *ProductHandler) GetProductHistory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if _, rr := entitypkg.ParseID(id); rr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: "id is required"})
+		return
+	}
+
+	entries, err := ph.AuditDB.WithContext(r.Context()).FindByEntity(productsTable, id)
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	if len(entries) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(Error{Message: "history not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(entries)
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+}
+
+// GetProductPrices godoc
+// @Summary Histórico de preços de um produto
+// @Description Lista os preços de um produto com a data a partir da qual cada um vigorou, do mais recente para o mais antigo
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do produto" Format(uuid)
+// @Success 200 {array} entity.ProductPrice "Preços encontrados com sucesso"
+// @Failure 400 {object} Error "Dados inválidos"
+// @Failure 403 {object} Error "Escopo insuficiente"
+// @Failure 404 {object} Error "Preços não encontrados"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /products/{id}/prices [get]
+// @Security ApiKeyAuth
+// @Security APIKeyHeader
+func (ph *ProductHandler) GetProductPrices(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if _, rr := entitypkg.ParseID(id); rr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: "id is required"})
+		return
+	}
+
+	prices, err := ph.PriceDB.WithContext(r.Context()).FindByProduct(id)
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	if len(prices) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(Error{Message: "prices not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(prices)
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+}
+
 // GetProducts godoc
 // @Summary Busca todos os produtos
 // @Description Busca todos os produtos
@@ -220,10 +394,12 @@ func (ph *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request)
 // @Param limit query string true "Número de itens por página" default(10)
 // @Param sort query string true "Ordenação" default(asc)
 // @Success 200 {array} entity.Product "Produtos encontrados com sucesso"
+// @Failure 403 {object} Error "Escopo insuficiente"
 // @Failure 404 {object} Error "Produtos não encontrados"
 // @Failure 500 {object} Error "Erro interno"
 // @Router /products [get]
 // @Security ApiKeyAuth
+// @Security APIKeyHeader
 func (ph *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
 	pageParam := chi.URLParam(r, "page")
 	limitParam := chi.URLParam(r, "limit")
@@ -239,7 +415,7 @@ func (ph *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
 		limit = 0
 	}
 
-	products, err := ph.ProductDB.FindAll(page, limit, sort)
+	products, err := ph.ProductDB.WithContext(r.Context()).FindAll(page, limit, sort)
 
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
@@ -247,6 +423,12 @@ func (ph *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err = setLowestPrices(r, ph.PriceDB, products...); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
 	w.WriteHeader(http.StatusOK)
 	err = json.NewEncoder(w).Encode(products)
 
@@ -256,3 +438,213 @@ func (ph *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// BulkProducts godoc
+// @Summary Executa operações em lote nos produtos
+// @Description Executa uma lista de operações de criação, atualização e remoção de produtos, na ordem em que foram enviadas.
+// @Description No modo atomic todas as operações são aplicadas ou nenhuma é; no modo best_effort cada operação é aplicada de forma independente.
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param request body dto.BulkProductInput true "Operações do lote"
+// @Param Idempotency-Key header string false "Chave de idempotência da requisição"
+// @Success 200 {object} dto.BulkProductOutput "Lote executado com sucesso"
+// @Success 207 {object} dto.BulkProductOutput "Lote executado parcialmente"
+// @Failure 400 {object} dto.BulkProductOutput "Dados inválidos"
+// @Failure 403 {object} Error "Escopo insuficiente"
+// @Failure 413 {object} Error "Lote maior que o permitido"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /products/bulk [post]
+// @Security ApiKeyAuth
+// @Security APIKeyHeader
+func (ph *ProductHandler) BulkProducts(w http.ResponseWriter, r *http.Request) {
+	input := dto.BulkProductInput{}
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	if input.Mode == "" {
+		input.Mode = BulkModeAtomic
+	}
+	if input.Mode != BulkModeAtomic && input.Mode != BulkModeBestEffort {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: ErrBulkInvalidMode.Error()})
+		return
+	}
+	if len(input.Operations) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: ErrBulkOperationsRequired.Error()})
+		return
+	}
+	if ph.BulkMaxBatchSize > 0 && len(input.Operations) > ph.BulkMaxBatchSize {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		_ = json.NewEncoder(w).Encode(Error{Message: ErrBulkBatchTooLarge.Error()})
+		return
+	}
+
+	output := dto.BulkProductOutput{
+		Mode:    input.Mode,
+		Results: make([]dto.BulkProductOperationOutput, len(input.Operations)),
+	}
+
+	productDB := ph.ProductDB.WithContext(r.Context())
+	status := http.StatusOK
+	if input.Mode == BulkModeAtomic {
+		err = productDB.Transaction(func(tx database.ProductInterface) error {
+			return ph.executeBulk(tx, input.Operations, output.Results, true)
+		})
+		if err != nil && !errors.Is(err, errBulkRolledBack) {
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+			return
+		}
+		if err != nil {
+			for i := range output.Results {
+				if output.Results[i].Status == "failed" {
+					continue
+				}
+				if output.Results[i].Operation == "create" {
+					output.Results[i].ID = ""
+				}
+				output.Results[i].Status = "skipped"
+			}
+			status = http.StatusBadRequest
+		}
+	} else {
+		_ = ph.executeBulk(productDB, input.Operations, output.Results, false)
+	}
+
+	for _, result := range output.Results {
+		if result.Status == "failed" {
+			output.Failed++
+		} else if result.Status != "skipped" {
+			output.Succeeded++
+		}
+	}
+	if input.Mode == BulkModeBestEffort && output.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+
+	w.WriteHeader(status)
+	err = json.NewEncoder(w).Encode(output)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+}
+
+// executeBulk aplica as operações em db na ordem da requisição, preenchendo
+// results na mesma ordem. Cada sequência de criações consecutivas é inserida
+// com CreateInBatches antes da operação seguinte. Quando atomic é verdadeiro,
+// a primeira falha interrompe o lote com errBulkRolledBack.
+func (ph *ProductHandler) executeBulk(db database.ProductInterface, operations []dto.BulkProductOperationInput, results []dto.BulkProductOperationOutput, atomic bool) error {
+	var creates []*entity.Product
+	var createIndexes []int
+
+	// Os resultados são identificados antes da execução, para que as operações
+	// não alcançadas por um lote interrompido também sejam descritas.
+	for i, op := range operations {
+		results[i] = dto.BulkProductOperationOutput{Index: i, Operation: op.Operation, ID: op.ID}
+	}
+
+	// flush insere as criações pendentes.
+	flush := func() error {
+		pending, indexes := creates, createIndexes
+		creates, createIndexes = nil, nil
+		err := db.CreateInBatches(pending, bulkInsertBatchSize)
+		if err == nil {
+			return nil
+		}
+		// No modo atomic não é possível refazer as criações dentro da transação:
+		// todas falham e o lote é desfeito como nas demais falhas.
+		if atomic {
+			for _, i := range indexes {
+				results[i].ID = ""
+				results[i].Status = "failed"
+				results[i].Error = err.Error()
+			}
+			return errBulkRolledBack
+		}
+
+		// Um lote com falha é refeito item a item para identificar a operação com erro.
+		for j, p := range pending {
+			if err := db.Create(p); err != nil {
+				results[indexes[j]].Status = "failed"
+				results[indexes[j]].Error = err.Error()
+			}
+		}
+		return nil
+	}
+
+	for i, op := range operations {
+		if op.Operation != "create" && len(creates) > 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		switch op.Operation {
+		case "create":
+			var p *entity.Product
+			p, err = entity.NewProduct(op.Name, op.Description, op.Price)
+			if err == nil {
+				creates = append(creates, p)
+				createIndexes = append(createIndexes, i)
+				results[i].ID = p.ID.String()
+				results[i].Status = "created"
+			}
+		case "update":
+			err = updateBulkProduct(db, op)
+			if err == nil {
+				results[i].Status = "updated"
+			}
+		case "delete":
+			if _, err = entitypkg.ParseID(op.ID); err == nil {
+				err = db.Delete(op.ID)
+			}
+			if err == nil {
+				results[i].Status = "deleted"
+			}
+		default:
+			err = ErrBulkInvalidOperation
+		}
+
+		if err != nil {
+			results[i].Status = "failed"
+			results[i].Error = err.Error()
+			if atomic {
+				return errBulkRolledBack
+			}
+		}
+	}
+
+	if len(creates) > 0 {
+		return flush()
+	}
+	return nil
+}
+
+func updateBulkProduct(db database.ProductInterface, op dto.BulkProductOperationInput) error {
+	if _, err := entitypkg.ParseID(op.ID); err != nil {
+		return err
+	}
+
+	product, err := db.FindById(op.ID)
+	if err != nil {
+		return err
+	}
+
+	product.Name = op.Name
+	product.Description = op.Description
+	product.Price = op.Price
+	if err := entity.ValidateProduct(product); err != nil {
+		return err
+	}
+
+	return db.Update(product)
+}
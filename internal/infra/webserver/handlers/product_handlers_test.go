@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"apis/internal/dto"
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// failingBatchDB simula uma falha do banco na inserção em lote.
+type failingBatchDB struct {
+	database.ProductInterface
+}
+
+func (f failingBatchDB) WithContext(ctx context.Context) database.ProductInterface {
+	return failingBatchDB{f.ProductInterface.WithContext(ctx)}
+}
+
+func (f failingBatchDB) CreateInBatches(products []*entity.Product, batchSize int) error {
+	if len(products) == 0 {
+		return nil
+	}
+	return errors.New("database is locked")
+}
+
+func (f failingBatchDB) Transaction(fn func(tx database.ProductInterface) error) error {
+	return f.ProductInterface.Transaction(func(tx database.ProductInterface) error {
+		return fn(failingBatchDB{tx})
+	})
+}
+
+// recordingDB registra a ordem em que as operações chegam ao banco.
+type recordingDB struct {
+	database.ProductInterface
+	calls *[]string
+}
+
+func (d recordingDB) WithContext(ctx context.Context) database.ProductInterface {
+	return recordingDB{d.ProductInterface.WithContext(ctx), d.calls}
+}
+
+func (d recordingDB) CreateInBatches(products []*entity.Product, batchSize int) error {
+	*d.calls = append(*d.calls, fmt.Sprintf("create %d", len(products)))
+	return d.ProductInterface.CreateInBatches(products, batchSize)
+}
+
+func (d recordingDB) Delete(id string) error {
+	*d.calls = append(*d.calls, "delete")
+	return d.ProductInterface.Delete(id)
+}
+
+func bulkRequest(t *testing.T, productDB database.ProductInterface, input dto.BulkProductInput) (int, dto.BulkProductOutput) {
+	body, _ := json.Marshal(input)
+	r := httptest.NewRequest(http.MethodPost, "/products/bulk", bytes.NewReader(body))
+	r = r.WithContext(database.ContextWithTenant(r.Context(), entitypkg.NewID()))
+	w := httptest.NewRecorder()
+	NewProductHandler(productDB, nil, nil).BulkProducts(w, r)
+
+	var output dto.BulkProductOutput
+	assert.Nil(t, json.NewDecoder(w.Body).Decode(&output))
+	return w.Code, output
+}
+
+func TestBulkProducts_AtomicFailureDescribesSkippedOperations(t *testing.T) {
+	db := newTestDB(t, &entity.Product{}, &entity.ProductRevision{}, &entity.ProductPrice{})
+	missing := entitypkg.NewID().String()
+
+	code, output := bulkRequest(t, database.NewProduct(db), dto.BulkProductInput{
+		Mode: BulkModeAtomic,
+		Operations: []dto.BulkProductOperationInput{
+			{Operation: "create", Name: "Desk", Description: "Oak desk", Price: 100},
+			{Operation: "delete", ID: missing},
+			{Operation: "update", ID: missing, Name: "Chair", Description: "Chair", Price: 50},
+			{Operation: "create", Name: "Lamp", Description: "Desk lamp", Price: 20},
+		},
+	})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, 1, output.Failed)
+	assert.Equal(t, 0, output.Succeeded)
+
+	expected := []dto.BulkProductOperationOutput{
+		{Index: 0, Operation: "create", Status: "skipped"},
+		{Index: 1, Operation: "delete", ID: missing, Status: "failed"},
+		{Index: 2, Operation: "update", ID: missing, Status: "skipped"},
+		{Index: 3, Operation: "create", Status: "skipped"},
+	}
+	for i, result := range output.Results {
+		result.Error = ""
+		assert.Equal(t, expected[i], result, "result %d should describe its operation", i)
+	}
+
+	var count int64
+	db.Model(&entity.Product{}).Count(&count)
+	assert.Equal(t, int64(0), count, "batch should be rolled back")
+}
+
+func TestBulkProducts_AtomicBatchInsertFailure(t *testing.T) {
+	db := newTestDB(t, &entity.Product{}, &entity.ProductRevision{}, &entity.ProductPrice{})
+	code, output := bulkRequest(t, failingBatchDB{database.NewProduct(db)}, dto.BulkProductInput{
+		Mode: BulkModeAtomic,
+		Operations: []dto.BulkProductOperationInput{
+			{Operation: "create", Name: "Chair", Description: "Chair", Price: 50},
+			{Operation: "create", Name: "Lamp", Description: "Desk lamp", Price: 20},
+		},
+	})
+	assert.Equal(t, http.StatusBadRequest, code, "batch insert failure should roll back the batch")
+	assert.Equal(t, 2, output.Failed)
+	for i, result := range output.Results {
+		assert.Equal(t, i, result.Index)
+		assert.Equal(t, "create", result.Operation)
+		assert.Equal(t, "failed", result.Status)
+		assert.Equal(t, "database is locked", result.Error)
+		assert.Empty(t, result.ID, "rolled back creations should not expose an ID")
+	}
+}
+
+func TestBulkProducts_RunsOperationsInRequestOrder(t *testing.T) {
+	db := newTestDB(t, &entity.Product{}, &entity.ProductRevision{}, &entity.ProductPrice{})
+	missing := entitypkg.NewID().String()
+	var calls []string
+
+	code, output := bulkRequest(t, recordingDB{database.NewProduct(db), &calls}, dto.BulkProductInput{
+		Mode: BulkModeBestEffort,
+		Operations: []dto.BulkProductOperationInput{
+			{Operation: "create", Name: "Desk", Description: "Oak desk", Price: 100},
+			{Operation: "create", Name: "Chair", Description: "Chair", Price: 50},
+			{Operation: "delete", ID: missing},
+			{Operation: "create", Name: "Lamp", Description: "Desk lamp", Price: 20},
+			{Operation: "delete", ID: missing},
+		},
+	})
+	assert.Equal(t, http.StatusMultiStatus, code)
+	assert.Equal(t, 3, output.Succeeded)
+	assert.Equal(t, []string{"create 2", "delete", "create 1", "delete"}, calls, "consecutive creates should be batched in request order")
+}
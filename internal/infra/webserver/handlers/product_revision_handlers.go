@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"apis/internal/dto"
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"strconv"
+)
+
+type ProductRevisionHandler struct {
+	ProductDB  database.ProductInterface
+	RevisionDB database.ProductRevisionInterface
+	PriceDB    database.ProductPriceInterface
+}
+
+func NewProductRevisionHandler(productDB database.ProductInterface, revisionDB database.ProductRevisionInterface, priceDB database.ProductPriceInterface) *ProductRevisionHandler {
+	return &ProductRevisionHandler{
+		ProductDB:  productDB,
+		RevisionDB: revisionDB,
+		PriceDB:    priceDB,
+	}
+}
+
+// GetRevisions godoc
+// @Summary Lista as revisões de um produto
+// @Description Lista as revisões de um produto, da mais antiga para a mais recente
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do produto" Format(uuid)
+// @Success 200 {array} entity.ProductRevision "Revisões encontradas com sucesso"
+// @Failure 400 {object} Error "Dados inválidos"
+// @Failure 403 {object} Error "Escopo insuficiente"
+// @Failure 404 {object} Error "Revisões não encontradas"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /products/{id}/revisions [get]
+// @Security ApiKeyAuth
+// @Security APIKeyHeader
+func (rh *ProductRevisionHandler) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if _, rr := entitypkg.ParseID(id); rr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: "id is required"})
+		return
+	}
+
+	revisions, err := rh.RevisionDB.WithContext(r.Context()).FindByProduct(id)
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	if len(revisions) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(Error{Message: "revisions not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(revisions)
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+}
+
+// GetRevision godoc
+// @Summary Busca uma revisão de um produto
+// @Description Busca uma revisão de um produto pelo número
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do produto" Format(uuid)
+// @Param n path int true "Número da revisão"
+// @Success 200 {object} entity.ProductRevision "Revisão encontrada com sucesso"
+// @Failure 400 {object} Error "Dados inválidos"
+// @Failure 403 {object} Error "Escopo insuficiente"
+// @Failure 404 {object} Error "Revisão não encontrada"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /products/{id}/revisions/{n} [get]
+// @Security ApiKeyAuth
+// @Security APIKeyHeader
+func (rh *ProductRevisionHandler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if _, rr := entitypkg.ParseID(id); rr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: "id is required"})
+		return
+	}
+
+	number, err := strconv.Atoi(chi.URLParam(r, "n"))
+	if err != nil || number < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: "invalid revision number"})
+		return
+	}
+
+	revision, err := rh.RevisionDB.WithContext(r.Context()).FindByNumber(id, number)
+
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(revision)
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+}
+
+// DiffRevisions godoc
+// @Summary Compara duas revisões de um produto
+// @Description Lista os campos que mudaram entre duas revisões de um produto
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do produto" Format(uuid)
+// @Param from query int true "Número da revisão inicial"
+// @Param to query int true "Número da revisão final"
+// @Success 200 {object} dto.ProductRevisionDiffOutput "Diferença calculada com sucesso"
+// @Failure 400 {object} Error "Dados inválidos"
+// @Failure 403 {object} Error "Escopo insuficiente"
+// @Failure 404 {object} Error "Revisão não encontrada"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /products/{id}/revisions/diff [get]
+// @Security ApiKeyAuth
+// @Security APIKeyHeader
+func (rh *ProductRevisionHandler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if _, rr := entitypkg.ParseID(id); rr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: "id is required"})
+		return
+	}
+
+	from, err := strconv.Atoi(r.URL.Query().Get("from"))
+	if err != nil || from < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: "invalid from"})
+		return
+	}
+	to, err := strconv.Atoi(r.URL.Query().Get("to"))
+	if err != nil || to < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: "invalid to"})
+		return
+	}
+
+	revisionDB := rh.RevisionDB.WithContext(r.Context())
+	fromRevision, err := revisionDB.FindByNumber(id, from)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+	toRevision, err := revisionDB.FindByNumber(id, to)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	changes, err := entity.DiffFields(fromRevision.Snapshot(), toRevision.Snapshot())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	err = json.NewEncoder(w).Encode(dto.ProductRevisionDiffOutput{
+		From:    from,
+		To:      to,
+		Changes: changes,
+	})
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+}
+
+// RevertRevision godoc
+// @Summary Restaura uma revisão de um produto
+// @Description Restaura o estado de uma revisão anterior, gravando-o como uma nova revisão
+// @Tags products
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do produto" Format(uuid)
+// @Param n path int true "Número da revisão"
+// @Success 202 {object} dto.UpdateProductOutput "Produto restaurado com sucesso"
+// @Failure 400 {object} Error "Dados inválidos"
+// @Failure 403 {object} Error "Escopo insuficiente"
+// @Failure 404 {object} Error "Produto ou revisão não encontrados"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /products/{id}/revisions/{n}/revert [post]
+// @Security ApiKeyAuth
+// @Security APIKeyHeader
+func (rh *ProductRevisionHandler) RevertRevision(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if _, rr := entitypkg.ParseID(id); rr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: "id is required"})
+		return
+	}
+
+	number, err := strconv.Atoi(chi.URLParam(r, "n"))
+	if err != nil || number < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: "invalid revision number"})
+		return
+	}
+
+	productDB := rh.ProductDB.WithContext(r.Context())
+	product, err := productDB.FindById(id)
+
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	revision, err := rh.RevisionDB.WithContext(r.Context()).FindByNumber(id, number)
+
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	revision.ApplyTo(product)
+	if err = entity.ValidateProduct(product); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	err = productDB.Update(product)
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	if err = setLowestPrices(r, rh.PriceDB, product); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	err = json.NewEncoder(w).Encode(dto.UpdateProductOutput{
+		ID:             product.ID.String(),
+		Name:           product.Name,
+		Description:    product.Description,
+		Price:          product.Price,
+		LowestPrice30d: product.LowestPrice30d,
+	})
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"apis/internal/dto"
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	"encoding/json"
+	"net/http"
+)
+
+type TenantHandler struct {
+	TenantDB database.TenantInterface
+}
+
+func NewTenantHandler(db database.TenantInterface) *TenantHandler {
+	return &TenantHandler{
+		TenantDB: db,
+	}
+}
+
+// CreateTenant godoc
+// @Summary Cria um tenant
+// @Description Cria um tenant. O slug identifica o tenant no header X-Tenant do cadastro e do login. Restrito aos administradores do tenant padrão.
+// @Tags tenants
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateTenantInput true "Dados do tenant"
+// @Success 201 {object} entity.Tenant "Tenant criado com sucesso"
+// @Failure 400 {object} Error "Dados inválidos"
+// @Failure 403 {object} Error "Acesso negado"
+// @Failure 409 {object} Error "Slug já utilizado"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /tenants [post]
+// @Security ApiKeyAuth
+func (th *TenantHandler) CreateTenant(w http.ResponseWriter, r *http.Request) {
+	input := dto.CreateTenantInput{}
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	tenant, err := entity.NewTenant(input.Name, input.Slug)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	tenantDB := th.TenantDB.WithContext(r.Context())
+	if _, err := tenantDB.FindBySlug(tenant.Slug); err == nil {
+		w.WriteHeader(http.StatusConflict)
+		_ = json.NewEncoder(w).Encode(Error{Message: "slug already in use"})
+		return
+	}
+
+	err = tenantDB.Create(tenant)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(tenant)
+}
+
+// GetTenants godoc
+// @Summary Lista os tenants
+// @Description Lista os tenants cadastrados. Restrito aos administradores do tenant padrão.
+// @Tags tenants
+// @Accept json
+// @Produce json
+// @Success 200 {array} entity.Tenant "Tenants encontrados com sucesso"
+// @Failure 403 {object} Error "Acesso negado"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /tenants [get]
+// @Security ApiKeyAuth
+func (th *TenantHandler) GetTenants(w http.ResponseWriter, r *http.Request) {
+	tenants, err := th.TenantDB.WithContext(r.Context()).FindAll()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(tenants)
+}
@@ -4,23 +4,98 @@ import (
 	"apis/internal/dto"
 	"apis/internal/entity"
 	"apis/internal/infra/database"
+	"apis/internal/infra/mail"
+	"apis/internal/infra/metrics"
+	"apis/internal/infra/ratelimit"
 	entitypkg "apis/pkg/entity"
+	"apis/pkg/logger"
+	"apis/pkg/password"
+	"context"
 	"encoding/json"
+	"errors"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/jwtauth"
+	"go.uber.org/zap"
+	"math"
+	"net"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 )
 
+const (
+	DefaultMaxLoginAttempts  = 5
+	DefaultLoginLockout      = 15 * time.Minute
+	DefaultLoginBackoffBase  = 200 * time.Millisecond
+	DefaultLoginBackoffMax   = 5 * time.Second
+	DefaultLoginBackoffReset = 15 * time.Minute
+	DefaultPasswordResetTTL  = time.Hour
+
+	DefaultEmailVerificationTTL = 24 * time.Hour
+	DefaultMFAChallengeTTL      = 5 * time.Minute
+	DefaultMFAIssuer            = "products-api"
+)
+
+var (
+	errInvalidCredentials = errors.New("invalid credentials")
+	errEmailNotVerified   = errors.New("email not verified")
+	errAccountSuspended   = errors.New("account suspended")
+)
+
+// DefaultVerificationResendLimit limita os reenvios de verificação por e-mail.
+var DefaultVerificationResendLimit = ratelimit.Limit{Requests: 3, Period: time.Hour}
+
 type UserHandler struct {
 	UserDB       database.UserInterface
+	TokenDB      database.UserTokenInterface
+	RecoveryDB   database.RecoveryCodeInterface
+	SessionDB    database.SessionInterface
+	Mailer       mail.Mailer
 	Jwt          *jwtauth.JWTAuth
 	JwtExpiresIn int
+
+	MaxLoginAttempts int
+	LoginLockout     time.Duration
+	LoginBackoff     *ratelimit.Backoff
+
+	PasswordResetTTL time.Duration
+	PasswordResetURL string
+
+	EmailVerificationRequired bool
+	EmailVerificationTTL      time.Duration
+	EmailVerificationURL      string
+	VerificationResendStore   ratelimit.Store
+	VerificationResendLimit   ratelimit.Limit
+
+	MFAChallengeTTL time.Duration
+	MFAIssuer       string
+
+	PasswordPolicy *password.Policy
 }
 
-func NewUserHandler(db database.UserInterface) *UserHandler {
+func NewUserHandler(db database.UserInterface, tokenDB database.UserTokenInterface, recoveryDB database.RecoveryCodeInterface, sessionDB database.SessionInterface, mailer mail.Mailer) *UserHandler {
 	return &UserHandler{
-		UserDB: db,
+		UserDB:           db,
+		TokenDB:          tokenDB,
+		RecoveryDB:       recoveryDB,
+		SessionDB:        sessionDB,
+		Mailer:           mailer,
+		MaxLoginAttempts: DefaultMaxLoginAttempts,
+		LoginLockout:     DefaultLoginLockout,
+		LoginBackoff:     ratelimit.NewBackoff(DefaultLoginBackoffBase, DefaultLoginBackoffMax, DefaultLoginBackoffReset),
+		PasswordResetTTL: DefaultPasswordResetTTL,
+
+		EmailVerificationTTL:    DefaultEmailVerificationTTL,
+		VerificationResendStore: ratelimit.NewMemoryStore(),
+		VerificationResendLimit: DefaultVerificationResendLimit,
+
+		MFAChallengeTTL: DefaultMFAChallengeTTL,
+		MFAIssuer:       DefaultMFAIssuer,
+
+		PasswordPolicy: password.NewPolicy(password.DefaultMinLength),
 	}
 }
 
@@ -32,20 +107,20 @@ func (uh *UserHandler) GetAuth(r *http.Request) (*jwtauth.JWTAuth, int) {
 
 // GetJwt godoc
 // @Summary Gera um token JWT
-// @Description Gera um token JWT
+// @Description Gera um token JWT. Para contas com dois fatores, devolve mfa_token, que deve ser confirmado em /users/auth/mfa/verify.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param request body dto.LoginInput true "Credenciais dos usuário"
-// @Success 200 {object} dto.GetJWTOutput "Usuário autenticado com sucesso"
-// @Failure 400 {object} Error "Dados inválidos"
+// @Param X-Tenant header string false "Slug do tenant; sem ele vale o tenant padrão"
+// @Success 200 {object} dto.GetJWTOutput "Usuário autenticado com sucesso ou desafio de dois fatores"
+// @Failure 400 {object} Error "Dados inválidos ou escopo não permitido"
 // @Failure 401 {object} Error "Credenciais inválidas"
+// @Failure 403 {object} Error "E-mail não verificado ou conta suspensa (code account_suspended)"
+// @Failure 429 {object} Error "Limite de requisições excedido"
 // @Failure 500 {object} Error "Erro interno"
 // @Router /users/auth/generate_token [post]
 func (uh *UserHandler) GetJwt(w http.ResponseWriter, r *http.Request) {
-
-	jwt, jwtExpiresIn := uh.GetAuth(r)
-
 	input := dto.LoginInput{}
 	err := json.NewDecoder(r.Body).Decode(&input)
 	if err != nil {
@@ -54,26 +129,146 @@ func (uh *UserHandler) GetJwt(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user, err := uh.UserDB.FindByEmail(input.Email)
+	user, err := uh.checkCredentials(r, input.Email, input.Password)
+	if errors.Is(err, errAccountSuspended) {
+		writeAccountSuspended(w)
+		return
+	}
+	if errors.Is(err, errEmailNotVerified) {
+		http.Error(w, "email not verified", http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(Error{Message: "email not verified"})
+		return
+	}
+	if err != nil {
+		http.Error(w, "invalid credentials", http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(Error{Message: "invalid credentials"})
+		return
+	}
 
+	scopes, err := user.AllowedScopes().Grant(entity.ParseScopes(input.Scope))
 	if err != nil {
-		http.Error(w, "invalid credentials ", http.StatusUnauthorized)
-		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if !user.CheckPassword(input.Password) {
-		http.Error(w, "invalid credentials", http.StatusUnauthorized)
-		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+	// Com dois fatores ativos, a senha só dá direito a um desafio que deve ser
+	// trocado por um token de acesso em /users/auth/mfa/verify.
+	if user.TOTPEnabled {
+		uh.writeMFAChallenge(w, r, user)
 		return
 	}
 
-	_, tokenString, _ := jwt.Encode(map[string]interface{}{
-		"sub":   user.ID,
+	uh.writeAccessToken(w, r, user, scopes)
+}
+
+// checkCredentials valida e-mail e senha aplicando o bloqueio da conta e o
+// atraso progressivo. Usuário inexistente, conta bloqueada, conta desativada
+// e senha errada devolvem o mesmo errInvalidCredentials. Contas suspensas
+// devolvem errAccountSuspended, mas só depois de a senha ser conferida.
+func (uh *UserHandler) checkCredentials(r *http.Request, email, password string) (*entity.User, error) {
+	userDB := uh.UserDB.WithContext(r.Context())
+	emailKey := loginEmailKey(email)
+	ipKey := "ip:" + remoteIP(r)
+
+	user, err := userDB.FindByEmail(email)
+	if err != nil {
+		entity.CheckDummyPassword(password)
+		uh.delayLoginFailure(r, emailKey, ipKey)
+		return nil, errInvalidCredentials
+	}
+
+	now := time.Now()
+	locked := user.IsLocked(now)
+	validPassword, rehashed := user.VerifyPassword(password)
+	if locked || !validPassword {
+		if !locked {
+			uh.recordLoginFailure(r.Context(), user.ID.String())
+		}
+		uh.delayLoginFailure(r, emailKey, ipKey)
+		return nil, errInvalidCredentials
+	}
+
+	switch user.Status {
+	case entity.UserStatusSuspended:
+		uh.delayLoginFailure(r, emailKey, ipKey)
+		return nil, errAccountSuspended
+	case entity.UserStatusDeactivated:
+		uh.delayLoginFailure(r, emailKey, ipKey)
+		return nil, errInvalidCredentials
+	}
+
+	// Sem a verificação obrigatória, o token indica que o e-mail não foi
+	// confirmado pela claim email_verified.
+	if uh.EmailVerificationRequired && !user.IsEmailVerified() {
+		metrics.LoginAttemptsTotal.WithLabelValues(metrics.LoginFailure).Inc()
+		return nil, errEmailNotVerified
+	}
+
+	// Grava o hash refeito com o algoritmo atual junto com o fim das falhas.
+	if rehashed || user.FailedLoginAttempts > 0 || user.LockedUntil != nil {
+		user.Unlock()
+		if err := userDB.Update(user); err != nil {
+			logger.FromContext(r.Context()).Error("failed to update user after login", zap.Error(err))
+		}
+	}
+	uh.LoginBackoff.Reset(emailKey)
+	return user, nil
+}
+
+// recordLoginFailure conta a senha errada no cadastro do usuário fora da
+// requisição. Gravar a falha durante a requisição a deixaria mais lenta que a
+// de um e-mail inexistente, revelando quais e-mails estão cadastrados.
+func (uh *UserHandler) recordLoginFailure(ctx context.Context, userID string) {
+	ctx = context.WithoutCancel(ctx)
+	log := logger.FromContext(ctx)
+	userDB := uh.UserDB.WithContext(ctx)
+	go func() {
+		locked, err := userDB.RegisterLoginFailure(userID, time.Now(), uh.MaxLoginAttempts, uh.LoginLockout)
+		if err != nil {
+			log.Error("failed to record login failure", zap.Error(err))
+			return
+		}
+		if locked {
+			log.Warn("user locked after failed logins", zap.String("user_id", userID))
+		}
+	}()
+}
+
+// accessTokenClaims registra uma nova sessão e monta as claims do token de
+// acesso de um usuário com os escopos concedidos.
+func (uh *UserHandler) accessTokenClaims(r *http.Request, user *entity.User, scopes entity.Scopes) (map[string]interface{}, error) {
+	_, jwtExpiresIn := uh.GetAuth(r)
+	ttl := time.Second * time.Duration(jwtExpiresIn)
+
+	session := entity.NewSession(user.ID, r.UserAgent(), remoteIP(r), ttl)
+	if err := uh.SessionDB.WithContext(r.Context()).Create(session); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"sub":   user.ID.String(),
 		"name":  user.Name,
 		"email": user.Email,
-		"exp":   time.Now().Add(time.Second * time.Duration(jwtExpiresIn)).Unix(),
-	})
+		"role":  user.Role,
+		"scope": scopes.String(),
+		"exp":   session.ExpiresAt.Unix(),
+
+		"email_verified":      user.IsEmailVerified(),
+		entity.SessionIDClaim: session.ID.String(),
+		entity.TenantIDClaim:  user.TenantID.String(),
+	}, nil
+}
+
+func (uh *UserHandler) writeAccessToken(w http.ResponseWriter, r *http.Request, user *entity.User, scopes entity.Scopes) {
+	jwt, _ := uh.GetAuth(r)
+	claims, err := uh.accessTokenClaims(r, user, scopes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	metrics.LoginAttemptsTotal.WithLabelValues(metrics.LoginSuccess).Inc()
+
+	_, tokenString, _ := jwt.Encode(claims)
 
 	accessToken := dto.GetJWTOutput{AccessToken: tokenString}
 
@@ -87,6 +282,340 @@ func (uh *UserHandler) GetJwt(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// loginFailed atrasa a resposta de forma progressiva conforme as falhas
+// recentes do e-mail e do IP e responde com credenciais inválidas.
+func (uh *UserHandler) loginFailed(w http.ResponseWriter, r *http.Request, keys ...string) {
+	uh.delayLoginFailure(r, keys...)
+	http.Error(w, "invalid credentials", http.StatusUnauthorized)
+	_ = json.NewEncoder(w).Encode(Error{Message: "invalid credentials"})
+}
+
+func (uh *UserHandler) delayLoginFailure(r *http.Request, keys ...string) {
+	metrics.LoginAttemptsTotal.WithLabelValues(metrics.LoginFailure).Inc()
+
+	delay := uh.LoginBackoff.Fail(keys...)
+	select {
+	case <-time.After(delay):
+	case <-r.Context().Done():
+	}
+}
+
+func loginEmailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// currentSessionID devolve a sessão do token da requisição, quando houver.
+func currentSessionID(r *http.Request) string {
+	_, claims, err := jwtauth.FromContext(r.Context())
+	if err != nil {
+		return ""
+	}
+	sid, _ := claims[entity.SessionIDClaim].(string)
+	return sid
+}
+
+// currentUserID devolve o sub do JWT da requisição autenticada.
+func currentUserID(r *http.Request) string {
+	_, claims, err := jwtauth.FromContext(r.Context())
+	if err != nil {
+		return ""
+	}
+	sub, _ := claims["sub"].(string)
+	return sub
+}
+
+// isAdmin indica se o token da requisição pertence a um administrador. O
+// Authenticate troca a claim role pelo papel atual do usuário.
+func isAdmin(r *http.Request) bool {
+	_, claims, err := jwtauth.FromContext(r.Context())
+	if err != nil {
+		return false
+	}
+	role, _ := claims["role"].(string)
+	return role == entity.RoleAdmin
+}
+
+// isAPIKeyRequest indica se a requisição foi autenticada por chave de API.
+func isAPIKeyRequest(r *http.Request) bool {
+	_, claims, err := jwtauth.FromContext(r.Context())
+	if err != nil {
+		return false
+	}
+	_, ok := claims[entity.APIKeyIDClaim]
+	return ok
+}
+
+// authorizeUser permite que usuários comuns acessem somente o próprio cadastro.
+func authorizeUser(w http.ResponseWriter, r *http.Request, id string) bool {
+	if id == currentUserID(r) || isAdmin(r) {
+		return true
+	}
+	http.Error(w, "forbidden", http.StatusForbidden)
+	return false
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ForgotPassword godoc
+// @Summary Solicita a redefinição de senha
+// @Description Envia por e-mail um link de redefinição de senha de uso único. A resposta é a mesma para e-mails cadastrados ou não.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body dto.ForgotPasswordInput true "E-mail do usuário"
+// @Param X-Tenant header string false "Slug do tenant; sem ele vale o tenant padrão"
+// @Success 202 "Solicitação aceita"
+// @Failure 400 {object} Error "Dados inválidos"
+// @Failure 429 {object} Error "Limite de requisições excedido"
+// @Router /users/auth/password/forgot [post]
+func (uh *UserHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	input := dto.ForgotPasswordInput{}
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if input.Email == "" {
+		http.Error(w, "email is required", http.StatusBadRequest)
+		return
+	}
+
+	// O envio acontece em segundo plano para que o tempo de resposta não
+	// revele se o e-mail está cadastrado.
+	go uh.sendPasswordReset(context.WithoutCancel(r.Context()), input.Email)
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (uh *UserHandler) sendPasswordReset(ctx context.Context, email string) {
+	user, err := uh.UserDB.WithContext(ctx).FindByEmail(email)
+	if err != nil {
+		return
+	}
+	uh.sendUserToken(ctx, user, entity.TokenPurposePasswordReset, uh.PasswordResetTTL, mail.TemplatePasswordReset, uh.PasswordResetURL)
+}
+
+func (uh *UserHandler) sendEmailVerification(ctx context.Context, user *entity.User) {
+	uh.sendUserToken(ctx, user, entity.TokenPurposeEmailVerification, uh.EmailVerificationTTL, mail.TemplateEmailVerification, uh.EmailVerificationURL)
+}
+
+// sendUserToken substitui os tokens anteriores do usuário para a finalidade
+// e envia o novo token por e-mail. Executado em segundo plano, apenas
+// registra as falhas no log.
+func (uh *UserHandler) sendUserToken(ctx context.Context, user *entity.User, purpose string, ttl time.Duration, template, url string) {
+	log := logger.FromContext(ctx).With(zap.String("purpose", purpose))
+
+	tokenDB := uh.TokenDB.WithContext(ctx)
+	if err := tokenDB.DeleteByUser(user.ID.String(), purpose); err != nil {
+		log.Error("failed to delete user tokens", zap.Error(err))
+		return
+	}
+	token, plain, err := entity.NewUserToken(user.ID, purpose, ttl)
+	if err != nil {
+		log.Error("failed to generate user token", zap.Error(err))
+		return
+	}
+	if err := tokenDB.Create(token); err != nil {
+		log.Error("failed to create user token", zap.Error(err))
+		return
+	}
+
+	message, err := mail.Render(template, user.Email, mail.TokenData{
+		Name:      user.Name,
+		URL:       url + "?token=" + plain,
+		ExpiresIn: ttl.String(),
+	})
+	if err != nil {
+		log.Error("failed to render mail", zap.Error(err))
+		return
+	}
+	if err := uh.Mailer.Send(ctx, message); err != nil {
+		log.Error("failed to send mail", zap.Error(err))
+	}
+}
+
+// ResetPassword godoc
+// @Summary Redefine a senha
+// @Description Troca a senha do usuário usando o token recebido por e-mail. O token só pode ser usado uma vez e todas as sessões do usuário são encerradas.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body dto.ResetPasswordInput true "Token e nova senha"
+// @Success 204 "Senha redefinida com sucesso"
+// @Failure 400 {object} Error "Token inválido ou expirado, ou senha fora da política"
+// @Failure 429 {object} Error "Limite de requisições excedido"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /users/auth/password/reset [post]
+func (uh *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	input := dto.ResetPasswordInput{}
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if input.Token == "" || input.Password == "" {
+		http.Error(w, "token and password are required", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	tokenDB := uh.TokenDB.WithContext(r.Context())
+	token, err := tokenDB.FindByToken(entity.TokenPurposePasswordReset, input.Token)
+	if err != nil || !token.IsValid(now) {
+		http.Error(w, "invalid or expired token", http.StatusBadRequest)
+		return
+	}
+
+	user, err := uh.UserDB.WithContext(r.Context()).FindByIdAcrossTenants(token.UserID.String())
+	if err != nil {
+		http.Error(w, "invalid or expired token", http.StatusBadRequest)
+		return
+	}
+	// O token não identifica o tenant: o fluxo segue no tenant do usuário.
+	r = r.WithContext(database.ContextWithTenant(r.Context(), user.TenantID))
+	userDB := uh.UserDB.WithContext(r.Context())
+	if err := uh.PasswordPolicy.Validate(input.Password, user.Email); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := user.SetPassword(input.Password); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = tokenDB.Use(token, now)
+	if errors.Is(err, database.ErrUserTokenUsed) {
+		http.Error(w, "invalid or expired token", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	user.Unlock()
+	err = userDB.Update(user)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	uh.LoginBackoff.Reset(loginEmailKey(user.Email))
+
+	// Quem pediu a troca pode não ser o dono das sessões abertas.
+	if _, err := uh.SessionDB.WithContext(r.Context()).RevokeAll(user.ID.String(), time.Now(), ""); err != nil {
+		logger.FromContext(r.Context()).Error("failed to revoke sessions", zap.Error(err))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VerifyEmail godoc
+// @Summary Confirma o e-mail do usuário
+// @Description Confirma o e-mail usando o token enviado no cadastro. O token só pode ser usado uma vez.
+// @Tags users
+// @Produce json
+// @Param token query string true "Token de verificação"
+// @Success 204 "E-mail confirmado com sucesso"
+// @Failure 400 {object} Error "Token inválido ou expirado"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /users/auth/verify [get]
+func (uh *UserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	plain := r.URL.Query().Get("token")
+	if plain == "" {
+		http.Error(w, "token is required", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	tokenDB := uh.TokenDB.WithContext(r.Context())
+	token, err := tokenDB.FindByToken(entity.TokenPurposeEmailVerification, plain)
+	if err != nil || !token.IsValid(now) {
+		http.Error(w, "invalid or expired token", http.StatusBadRequest)
+		return
+	}
+
+	user, err := uh.UserDB.WithContext(r.Context()).FindByIdAcrossTenants(token.UserID.String())
+	if err != nil {
+		http.Error(w, "invalid or expired token", http.StatusBadRequest)
+		return
+	}
+	// O token não identifica o tenant: o fluxo segue no tenant do usuário.
+	r = r.WithContext(database.ContextWithTenant(r.Context(), user.TenantID))
+	userDB := uh.UserDB.WithContext(r.Context())
+
+	err = tokenDB.Use(token, now)
+	if errors.Is(err, database.ErrUserTokenUsed) {
+		http.Error(w, "invalid or expired token", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	user.VerifyEmail(now)
+	err = userDB.Update(user)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResendVerification godoc
+// @Summary Reenvia o e-mail de verificação
+// @Description Reenvia o link de verificação para contas ainda não confirmadas. A resposta é a mesma para e-mails cadastrados ou não.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body dto.ResendVerificationInput true "E-mail do usuário"
+// @Param X-Tenant header string false "Slug do tenant; sem ele vale o tenant padrão"
+// @Success 202 "Solicitação aceita"
+// @Failure 400 {object} Error "Dados inválidos"
+// @Failure 429 {object} Error "Limite de reenvios excedido"
+// @Router /users/auth/verify/resend [post]
+func (uh *UserHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	input := dto.ResendVerificationInput{}
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if input.Email == "" {
+		http.Error(w, "email is required", http.StatusBadRequest)
+		return
+	}
+
+	// O limite é aplicado por e-mail, exista ele ou não.
+	if uh.VerificationResendLimit.Enabled() {
+		result, err := uh.VerificationResendStore.Take(r.Context(), loginEmailKey(input.Email), uh.VerificationResendLimit)
+		if err == nil && !result.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+			http.Error(w, "too many verification requests", http.StatusTooManyRequests)
+			return
+		}
+	}
+
+	go func(ctx context.Context) {
+		user, err := uh.UserDB.WithContext(ctx).FindByEmail(input.Email)
+		if err != nil || user.IsEmailVerified() {
+			return
+		}
+		uh.sendEmailVerification(ctx, user)
+	}(context.WithoutCancel(r.Context()))
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
 // Create user godoc
 // @Summary Cria um usuário
 // @Description Cria um usuário
@@ -94,8 +623,13 @@ func (uh *UserHandler) GetJwt(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param request body dto.CreteUserInput true "Dados do usuário"
+// @Param Idempotency-Key header string false "Chave de idempotência da requisição"
+// @Param X-Tenant header string false "Slug do tenant; sem ele vale o tenant padrão"
 // @Success 201 {object} dto.CreateUserOutput "Usuário criado com sucesso"
-// @Failure 400 {object} Error "Dados inválidos"
+// @Failure 400 {object} Error "Dados inválidos ou senha fora da política"
+// @Failure 409 {object} Error "E-mail já cadastrado no tenant ou requisição com a mesma chave em andamento"
+// @Failure 422 {object} Error "Chave de idempotência reutilizada com outro corpo"
+// @Failure 429 {object} Error "Limite de requisições excedido"
 // @Failure 500 {object} Error "Erro interno"
 // @Router /users [post]
 func (uh *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -106,6 +640,11 @@ func (uh *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
 		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
 		return
 	}
+	if err = uh.PasswordPolicy.Validate(input.Password, input.Email); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
+		return
+	}
 	user, err := entity.NewUser(input.Name, input.Email, input.Password)
 
 	if err != nil {
@@ -114,13 +653,19 @@ func (uh *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = uh.UserDB.Create(user)
+	userDB := uh.UserDB.WithContext(r.Context())
+	if _, err := userDB.FindByEmail(user.Email); err == nil {
+		http.Error(w, "email already registered", http.StatusConflict)
+		return
+	}
+	err = userDB.Create(user)
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		_ = json.NewEncoder(w).Encode(Error{Message: err.Error()})
 		return
 	}
+	go uh.sendEmailVerification(context.WithoutCancel(r.Context()), user)
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(dto.CreateUserOutput{
 		ID:    user.ID.String(),
@@ -129,15 +674,49 @@ func (uh *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// GetUsers godoc
+// @Summary Lista os usuários
+// @Description Lista os usuários com paginação, ordenação, busca por nome ou e-mail e filtros. Restrito a administradores.
+// @Tags users
+// @Produce json
+// @Param q query string false "Busca por nome ou e-mail"
+// @Param role query string false "Papel" Enums(user, admin)
+// @Param status query string false "Situação" Enums(active, suspended, deactivated, verified, unverified, locked)
+// @Param created_from query string false "Criados a partir de (RFC 3339)"
+// @Param created_to query string false "Criados até (RFC 3339)"
+// @Param sort query string false "Campo de ordenação" Enums(created_at, name, email) default(created_at)
+// @Param order query string false "Direção da ordenação" Enums(asc, desc) default(asc)
+// @Param page query int false "Número da página" default(1)
+// @Param limit query int false "Número de itens por página" default(20)
+// @Success 200 {object} dto.UserListOutput "Usuários encontrados com sucesso"
+// @Failure 400 {object} Error "Parâmetros inválidos"
+// @Failure 401 {object} Error "Não autenticado"
+// @Failure 403 {object} Error "Acesso negado"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /users [get]
+// @Security ApiKeyAuth
+// @Security APIKeyHeader
 func (uh *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := uh.UserDB.FindAll()
+	filter, err := parseUserFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	users, total, err := uh.UserDB.WithContext(r.Context()).FindAll(filter)
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	err = json.NewEncoder(w).Encode(users)
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(dto.UserListOutput{
+		Items: users,
+		Total: total,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+	})
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -145,6 +724,65 @@ func (uh *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+const (
+	defaultUsersPageLimit = 20
+	maxUsersPageLimit     = 100
+)
+
+// parseUserFilter valida os parâmetros da listagem de usuários. A listagem é
+// sempre paginada para não carregar a tabela inteira.
+func parseUserFilter(query url.Values) (database.UserFilter, error) {
+	filter := database.UserFilter{
+		Query: strings.TrimSpace(query.Get("q")),
+		Role:  query.Get("role"),
+		Sort:  query.Get("sort"),
+		Order: query.Get("order"),
+		Page:  1,
+		Limit: defaultUsersPageLimit,
+	}
+
+	if filter.Role != "" && filter.Role != entity.RoleUser && filter.Role != entity.RoleAdmin {
+		return filter, errors.New("invalid role")
+	}
+	switch status := query.Get("status"); status {
+	case "", entity.UserStatusActive, entity.UserStatusSuspended, entity.UserStatusDeactivated,
+		database.UserStatusVerified, database.UserStatusUnverified, database.UserStatusLocked:
+		filter.Status = status
+	default:
+		return filter, errors.New("invalid status")
+	}
+	if filter.Sort != "" && !slices.Contains(database.UserSortFields, filter.Sort) {
+		return filter, errors.New("invalid sort")
+	}
+	if filter.Order != "" && filter.Order != "asc" && filter.Order != "desc" {
+		return filter, errors.New("invalid order")
+	}
+
+	var err error
+	if from := query.Get("created_from"); from != "" {
+		if filter.CreatedFrom, err = time.Parse(time.RFC3339, from); err != nil {
+			return filter, errors.New("invalid created_from")
+		}
+	}
+	if to := query.Get("created_to"); to != "" {
+		if filter.CreatedTo, err = time.Parse(time.RFC3339, to); err != nil {
+			return filter, errors.New("invalid created_to")
+		}
+	}
+
+	if page := query.Get("page"); page != "" {
+		if filter.Page, err = strconv.Atoi(page); err != nil || filter.Page < 1 {
+			return filter, errors.New("invalid page")
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit < 1 || filter.Limit > maxUsersPageLimit {
+			return filter, errors.New("invalid limit")
+		}
+	}
+	return filter, nil
+}
+
 func (uh *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
@@ -152,8 +790,11 @@ func (uh *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "id is required", http.StatusBadRequest)
 		return
 	}
+	if !authorizeUser(w, r, id) {
+		return
+	}
 
-	user, err := uh.UserDB.FindById(id)
+	user, err := uh.UserDB.WithContext(r.Context()).FindById(id)
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
@@ -171,6 +812,9 @@ func (uh *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "id is required", http.StatusBadRequest)
 		return
 	}
+	if !authorizeUser(w, r, id) {
+		return
+	}
 
 	input := dto.UpdateUserInput{}
 	err := json.NewDecoder(r.Body).Decode(&input)
@@ -178,36 +822,194 @@ func (uh *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	// Usuários comuns trocam a senha em /users/me/password, que exige a senha atual.
+	if input.Password != "" && !isAdmin(r) {
+		http.Error(w, "use /users/me/password to change the password", http.StatusForbidden)
+		return
+	}
+
+	if _, ok := uh.updateUser(w, r, id, input); !ok {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
 
-	user, err := uh.UserDB.FindById(id)
+// updateUser aplica os dados informados e reenvia a verificação quando o
+// e-mail muda. Em caso de erro, já escreve a resposta.
+func (uh *UserHandler) updateUser(w http.ResponseWriter, r *http.Request, id string, input dto.UpdateUserInput) (*entity.User, bool) {
+	userDB := uh.UserDB.WithContext(r.Context())
+	user, err := userDB.FindById(id)
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
-		return
+		return nil, false
 	}
 
+	emailChanged := input.Email != "" && input.Email != user.Email
+	if emailChanged {
+		if _, err := userDB.FindByEmail(input.Email); err == nil {
+			http.Error(w, "email already registered", http.StatusConflict)
+			return nil, false
+		}
+	}
 	user.
 		SetName(input.Name).
 		SetEmail(input.Email)
 	if input.Password != "" {
+		if err = uh.PasswordPolicy.Validate(input.Password, user.Email); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return nil, false
+		}
 		err = user.SetPassword(input.Password)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
-			return
+			return nil, false
 		}
 	}
 
-	err = uh.UserDB.Update(user)
+	err = userDB.Update(user)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if emailChanged {
+		go uh.sendEmailVerification(context.WithoutCancel(r.Context()), user)
+	}
+	return user, true
+}
+
+func (uh *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if _, rr := entitypkg.ParseID(id); rr != nil {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+	if !authorizeUser(w, r, id) {
+		return
+	}
+
+	userDB := uh.UserDB.WithContext(r.Context())
+	_, err := userDB.FindById(id)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	err = userDB.Delete(id)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UpdateUserStatus godoc
+// @Summary Altera a situação de um usuário
+// @Description Suspende, desativa ou reativa a conta, registrando o motivo. Contas que deixam de estar ativas têm as sessões encerradas e não podem usar seus tokens nem chaves de API. Restrito a administradores.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do usuário"
+// @Param request body dto.UpdateUserStatusInput true "Nova situação e motivo"
+// @Success 200 {object} entity.User "Situação alterada com sucesso"
+// @Failure 400 {object} Error "Dados inválidos"
+// @Failure 403 {object} Error "Acesso negado"
+// @Failure 404 {object} Error "Usuário não encontrado"
+// @Failure 409 {object} Error "Usuário apagado"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /users/{id}/status [put]
+// @Security ApiKeyAuth
+// @Security APIKeyHeader
+func (uh *UserHandler) UpdateUserStatus(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if _, rr := entitypkg.ParseID(id); rr != nil {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+	// Impede que o administrador tranque a si mesmo fora do sistema.
+	if id == currentUserID(r) {
+		http.Error(w, "cannot change your own status", http.StatusBadRequest)
+		return
+	}
+
+	input := dto.UpdateUserStatusInput{}
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userDB := uh.UserDB.WithContext(r.Context())
+	user, err := userDB.FindById(id)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	now := time.Now()
+	err = user.ChangeStatus(input.Status, strings.TrimSpace(input.Reason), now)
+	if errors.Is(err, entity.ErrUserErased) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = userDB.Update(user)
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	logger.FromContext(r.Context()).Info("user status changed",
+		zap.String("user_id", user.ID.String()),
+		zap.String("status", user.Status),
+	)
 
+	if !user.IsActive() {
+		if _, err := uh.SessionDB.WithContext(r.Context()).RevokeAll(user.ID.String(), now, ""); err != nil {
+			logger.FromContext(r.Context()).Error("failed to revoke sessions", zap.Error(err))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(user)
 }
 
-func (uh *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+// writeAccountSuspended responde ao login de uma conta suspensa com um código
+// próprio, para que o cliente não o confunda com credenciais inválidas.
+func writeAccountSuspended(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	_ = json.NewEncoder(w).Encode(Error{Message: errAccountSuspended.Error(), Code: ErrorCodeAccountSuspended})
+}
+
+// UnlockUser godoc
+// @Summary Desbloqueia um usuário
+// @Description Remove o bloqueio por excesso de tentativas de login. Restrito a administradores.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do usuário"
+// @Success 200 {object} entity.User "Usuário desbloqueado com sucesso"
+// @Failure 400 {object} Error "Dados inválidos"
+// @Failure 403 {object} Error "Acesso negado"
+// @Failure 404 {object} Error "Usuário não encontrado"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /users/{id}/unlock [post]
+// @Security ApiKeyAuth
+// @Security APIKeyHeader
+func (uh *UserHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
 	if _, rr := entitypkg.ParseID(id); rr != nil {
@@ -215,19 +1017,24 @@ func (uh *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	_, err := uh.UserDB.FindById(id)
+	userDB := uh.UserDB.WithContext(r.Context())
+	user, err := userDB.FindById(id)
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
-	err = uh.UserDB.Delete(id)
+	user.Unlock()
+	err = userDB.Update(user)
 
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	uh.LoginBackoff.Reset(loginEmailKey(user.Email))
 
-	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(user)
 }
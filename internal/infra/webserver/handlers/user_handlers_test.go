@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	"apis/internal/infra/metrics"
+	"apis/internal/infra/ratelimit"
+	entitypkg "apis/pkg/entity"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// slowWrite simula um banco em que cada gravação demora, para que uma
+// gravação feita durante a requisição apareça no tempo de resposta.
+const slowWrite = 200 * time.Millisecond
+
+func newTestDB(t *testing.T, models ...interface{}) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// O banco em memória existe apenas na conexão que o criou.
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func newLoginTest(t *testing.T) (*UserHandler, *http.Request, *entity.User) {
+	db := newTestDB(t, &entity.User{})
+	err := db.Callback().Update().Before("gorm:update").Register("test:slow_write", func(*gorm.DB) {
+		time.Sleep(slowWrite)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/users/auth/generate_token", nil)
+	r = r.WithContext(database.ContextWithTenant(r.Context(), entitypkg.NewID()))
+
+	userDB := database.NewUser(db)
+	user, _ := entity.NewUser("John", "john@example.com", "Tr0ub4dor&3x")
+	assert.Nil(t, userDB.WithContext(r.Context()).Create(user))
+
+	uh := NewUserHandler(userDB, nil, nil, nil, nil)
+	uh.LoginBackoff = ratelimit.NewBackoff(0, 0, time.Minute)
+	return uh, r, user
+}
+
+func TestCheckCredentials_TimingParity(t *testing.T) {
+	uh, r, user := newLoginTest(t)
+
+	elapsed := func(email string) time.Duration {
+		start := time.Now()
+		_, err := uh.checkCredentials(r, email, "wrong password")
+		assert.ErrorIs(t, err, errInvalidCredentials)
+		return time.Since(start)
+	}
+
+	var unknown, known time.Duration
+	for i := 1; i <= 3; i++ {
+		if d := elapsed("nobody@example.com"); unknown == 0 || d < unknown {
+			unknown = d
+		}
+		if d := elapsed(user.Email); known == 0 || d < known {
+			known = d
+		}
+		// A falha é gravada fora da requisição.
+		assert.Eventually(t, func() bool {
+			found, err := uh.UserDB.WithContext(r.Context()).FindById(user.ID.String())
+			return err == nil && found.FailedLoginAttempts == i
+		}, 5*slowWrite, 10*time.Millisecond, "login failure should be recorded")
+	}
+
+	assert.Less(t, known, unknown+slowWrite/2, "wrong password for a known email should take as long as an unknown email")
+}
+
+func TestCheckCredentials_ConcurrentFailuresLockAccount(t *testing.T) {
+	uh, r, user := newLoginTest(t)
+	uh.MaxLoginAttempts = 5
+	uh.LoginLockout = time.Hour
+
+	var wg sync.WaitGroup
+	for i := 0; i < uh.MaxLoginAttempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := uh.checkCredentials(r, user.Email, "wrong password")
+			assert.ErrorIs(t, err, errInvalidCredentials)
+		}()
+	}
+	wg.Wait()
+
+	assert.Eventually(t, func() bool {
+		found, err := uh.UserDB.WithContext(r.Context()).FindById(user.ID.String())
+		return err == nil && found.IsLocked(time.Now())
+	}, time.Duration(uh.MaxLoginAttempts+2)*slowWrite*2, 10*time.Millisecond, "concurrent failures should lock the account")
+}
+
+func TestCheckCredentials_SuspendedAccountCountsAsFailure(t *testing.T) {
+	uh, r, user := newLoginTest(t)
+	uh.LoginBackoff = ratelimit.NewBackoff(time.Millisecond, time.Second, time.Minute)
+	assert.Nil(t, user.ChangeStatus(entity.UserStatusSuspended, "", time.Now()))
+	assert.Nil(t, uh.UserDB.WithContext(r.Context()).Update(user))
+
+	failures := metrics.LoginAttemptsTotal.WithLabelValues(metrics.LoginFailure)
+	before := testutil.ToFloat64(failures)
+	_, err := uh.checkCredentials(r, user.Email, "Tr0ub4dor&3x")
+	assert.ErrorIs(t, err, errAccountSuspended)
+
+	assert.Equal(t, before+1, testutil.ToFloat64(failures), "suspended login should be counted as a failure")
+	assert.Equal(t, 2*time.Millisecond, uh.LoginBackoff.Fail(loginEmailKey(user.Email)), "suspended login should feed the backoff")
+}
+
+func TestUpdateUserStatus_ErasedUser(t *testing.T) {
+	db := newTestDB(t, &entity.User{})
+	ctx := database.ContextWithTenant(context.Background(), entitypkg.NewID())
+	userDB := database.NewUser(db)
+	user, _ := entity.NewUser("John", "john@example.com", "Tr0ub4dor&3x")
+	assert.Nil(t, userDB.WithContext(ctx).Create(user))
+	user.Anonymize(time.Now())
+	assert.Nil(t, userDB.WithContext(ctx).Update(user))
+
+	router := chi.NewRouter()
+	router.Put("/users/{id}/status", NewUserHandler(userDB, nil, nil, nil, nil).UpdateUserStatus)
+	r := httptest.NewRequest(http.MethodPut, "/users/"+user.ID.String()+"/status", strings.NewReader(`{"status":"active"}`))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r.WithContext(ctx))
+
+	assert.Equal(t, http.StatusConflict, w.Code, "erased user should not be reactivated")
+	found, err := userDB.WithContext(ctx).FindById(user.ID.String())
+	assert.Nil(t, err)
+	assert.Equal(t, entity.UserStatusDeactivated, found.Status)
+}
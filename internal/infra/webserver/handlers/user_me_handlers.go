@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"apis/internal/dto"
+	"apis/pkg/logger"
+	"encoding/json"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// GetMe godoc
+// @Summary Busca o usuário autenticado
+// @Description Busca o cadastro do usuário dono do token
+// @Tags users
+// @Produce json
+// @Success 200 {object} entity.User "Usuário encontrado com sucesso"
+// @Failure 401 {object} Error "Não autenticado"
+// @Failure 403 {object} Error "Escopo insuficiente"
+// @Router /users/me [get]
+// @Security ApiKeyAuth
+// @Security APIKeyHeader
+func (uh *UserHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	user, err := uh.UserDB.WithContext(r.Context()).FindById(currentUserID(r))
+	if err != nil {
+		http.Error(w, "user not found", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(user)
+}
+
+// UpdateMe godoc
+// @Summary Atualiza o usuário autenticado
+// @Description Altera nome e e-mail do usuário dono do token. Um novo e-mail precisa ser verificado novamente.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body dto.UpdateMeInput true "Dados do usuário"
+// @Success 200 {object} entity.User "Usuário atualizado com sucesso"
+// @Failure 400 {object} Error "Dados inválidos"
+// @Failure 401 {object} Error "Não autenticado"
+// @Failure 403 {object} Error "Escopo insuficiente"
+// @Failure 409 {object} Error "E-mail já cadastrado no tenant"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /users/me [patch]
+// @Security ApiKeyAuth
+// @Security APIKeyHeader
+func (uh *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	input := dto.UpdateMeInput{}
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	update := dto.UpdateUserInput{}
+	update.Name = input.Name
+	update.Email = input.Email
+	user, ok := uh.updateUser(w, r, currentUserID(r), update)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(user)
+}
+
+// ChangePassword godoc
+// @Summary Troca a senha do usuário autenticado
+// @Description Troca a senha do usuário dono do token mediante a senha atual e encerra as demais sessões. Não aceita chaves de API.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body dto.ChangePasswordInput true "Senha atual e nova senha"
+// @Success 204 "Senha alterada com sucesso"
+// @Failure 400 {object} Error "Dados inválidos ou senha fora da política"
+// @Failure 401 {object} Error "Não autenticado"
+// @Failure 403 {object} Error "Senha atual incorreta ou chave de API"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /users/me/password [post]
+// @Security ApiKeyAuth
+func (uh *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	if isAPIKeyRequest(r) {
+		http.Error(w, "password cannot be changed with an api key", http.StatusForbidden)
+		return
+	}
+
+	input := dto.ChangePasswordInput{}
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if input.CurrentPassword == "" || input.NewPassword == "" {
+		http.Error(w, "current_password and new_password are required", http.StatusBadRequest)
+		return
+	}
+
+	userDB := uh.UserDB.WithContext(r.Context())
+	user, err := userDB.FindById(currentUserID(r))
+	if err != nil {
+		http.Error(w, "user not found", http.StatusUnauthorized)
+		return
+	}
+
+	// Tentativas erradas sofrem o mesmo atraso progressivo do login.
+	emailKey := loginEmailKey(user.Email)
+	if !user.CheckPassword(input.CurrentPassword) {
+		uh.delayLoginFailure(r, emailKey)
+		http.Error(w, "current password is incorrect", http.StatusForbidden)
+		return
+	}
+
+	if err = uh.PasswordPolicy.Validate(input.NewPassword, user.Email); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = user.SetPassword(input.NewPassword)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = userDB.Update(user)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	uh.LoginBackoff.Reset(emailKey)
+	logger.FromContext(r.Context()).Info("user changed password", zap.String("user_id", user.ID.String()))
+
+	// Encerra as demais sessões, mantendo a que fez a troca.
+	if _, err := uh.SessionDB.WithContext(r.Context()).RevokeAll(user.ID.String(), time.Now(), currentSessionID(r)); err != nil {
+		logger.FromContext(r.Context()).Error("failed to revoke sessions", zap.Error(err))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
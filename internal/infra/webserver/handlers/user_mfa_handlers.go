@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"apis/internal/dto"
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	"apis/pkg/logger"
+	"apis/pkg/totp"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+	"go.uber.org/zap"
+)
+
+const totpQRCodeSize = 256
+
+func (uh *UserHandler) writeMFAChallenge(w http.ResponseWriter, r *http.Request, user *entity.User) {
+	tokenDB := uh.TokenDB.WithContext(r.Context())
+	if err := tokenDB.DeleteByUser(user.ID.String(), entity.TokenPurposeMFAChallenge); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	token, plain, err := entity.NewUserToken(user.ID, entity.TokenPurposeMFAChallenge, uh.MFAChallengeTTL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := tokenDB.Create(token); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(dto.GetJWTOutput{MFARequired: true, MFAToken: plain})
+}
+
+// VerifyMFA godoc
+// @Summary Conclui o login com dois fatores
+// @Description Troca o mfa_token devolvido por /users/auth/generate_token por um token JWT, usando o código do autenticador ou um código de recuperação
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body dto.MFAVerifyInput true "Desafio e código"
+// @Success 200 {object} dto.GetJWTOutput "Usuário autenticado com sucesso"
+// @Failure 400 {object} Error "Dados inválidos ou escopo não permitido"
+// @Failure 401 {object} Error "Desafio ou código inválido"
+// @Failure 403 {object} Error "Conta suspensa (code account_suspended)"
+// @Failure 429 {object} Error "Limite de requisições excedido"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /users/auth/mfa/verify [post]
+func (uh *UserHandler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	input := dto.MFAVerifyInput{}
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if input.MFAToken == "" || (input.Code == "" && input.RecoveryCode == "") {
+		http.Error(w, "mfa_token and code or recovery_code are required", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	tokenDB := uh.TokenDB.WithContext(r.Context())
+	token, err := tokenDB.FindByToken(entity.TokenPurposeMFAChallenge, input.MFAToken)
+	if err != nil || !token.IsValid(now) {
+		http.Error(w, "invalid or expired mfa token", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := uh.UserDB.WithContext(r.Context()).FindByIdAcrossTenants(token.UserID.String())
+	// A conta pode ter sido desativada depois de emitido o desafio.
+	if err != nil || user.Status == entity.UserStatusDeactivated {
+		http.Error(w, "invalid or expired mfa token", http.StatusUnauthorized)
+		return
+	}
+	// O token não identifica o tenant: o fluxo segue no tenant do usuário.
+	r = r.WithContext(database.ContextWithTenant(r.Context(), user.TenantID))
+	userDB := uh.UserDB.WithContext(r.Context())
+	if user.Status == entity.UserStatusSuspended {
+		writeAccountSuspended(w)
+		return
+	}
+	scopes, err := user.AllowedScopes().Grant(entity.ParseScopes(input.Scope))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	emailKey := loginEmailKey(user.Email)
+	ipKey := "ip:" + remoteIP(r)
+
+	valid := false
+	if !user.IsLocked(now) {
+		if input.Code != "" {
+			valid = user.ValidateTOTP(input.Code, now)
+		} else {
+			valid = uh.RecoveryDB.WithContext(r.Context()).Use(user.ID.String(), input.RecoveryCode, now) == nil
+		}
+	}
+
+	// Códigos errados contam para o bloqueio da conta como senhas erradas.
+	if !valid {
+		if !user.IsLocked(now) {
+			if _, err := userDB.RegisterLoginFailure(user.ID.String(), now, uh.MaxLoginAttempts, uh.LoginLockout); err != nil {
+				logger.FromContext(r.Context()).Error("failed to record mfa failure", zap.Error(err))
+			}
+		}
+		uh.loginFailed(w, r, emailKey, ipKey)
+		return
+	}
+
+	err = tokenDB.Use(token, now)
+	if errors.Is(err, database.ErrUserTokenUsed) {
+		http.Error(w, "invalid or expired mfa token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Grava o intervalo do código usado e zera as falhas.
+	user.Unlock()
+	err = userDB.Update(user)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	uh.LoginBackoff.Reset(emailKey)
+
+	uh.writeAccessToken(w, r, user, scopes)
+}
+
+// EnrollTOTP godoc
+// @Summary Inicia o cadastro de dois fatores
+// @Description Gera um segredo TOTP (RFC 6238) para o usuário autenticado, devolvendo o endereço otpauth:// e o QR code em PNG. O segundo fator só é exigido após a confirmação.
+// @Tags users
+// @Produce json
+// @Success 201 {object} dto.TOTPEnrollmentOutput "Segredo gerado com sucesso"
+// @Failure 401 {object} Error "Não autenticado"
+// @Failure 403 {object} Error "Escopo insuficiente ou chave de API"
+// @Failure 409 {object} Error "Dois fatores já ativos"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /users/me/mfa/totp [post]
+// @Security ApiKeyAuth
+func (uh *UserHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	// Uma chave vazada não pode trocar o segundo fator do dono.
+	if isAPIKeyRequest(r) {
+		http.Error(w, "mfa cannot be managed with an api key", http.StatusForbidden)
+		return
+	}
+
+	userDB := uh.UserDB.WithContext(r.Context())
+	user, err := userDB.FindById(currentUserID(r))
+	if err != nil {
+		http.Error(w, "user not found", http.StatusUnauthorized)
+		return
+	}
+	if user.TOTPEnabled {
+		http.Error(w, "totp already enabled", http.StatusConflict)
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	uri := totp.URI(uh.MFAIssuer, user.Email, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, totpQRCodeSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	user.EnrollTOTP(secret)
+	err = userDB.Update(user)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(dto.TOTPEnrollmentOutput{
+		Secret:     secret,
+		OTPAuthURI: uri,
+		QRCodePNG:  png,
+	})
+}
+
+// ConfirmTOTP godoc
+// @Summary Confirma o cadastro de dois fatores
+// @Description Ativa o segundo fator com um código do autenticador e devolve os códigos de recuperação, que não são mostrados novamente
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body dto.TOTPCodeInput true "Código do autenticador"
+// @Success 200 {object} dto.RecoveryCodesOutput "Dois fatores ativados com sucesso"
+// @Failure 400 {object} Error "Código inválido"
+// @Failure 401 {object} Error "Não autenticado"
+// @Failure 403 {object} Error "Escopo insuficiente ou chave de API"
+// @Failure 409 {object} Error "Dois fatores já ativos"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /users/me/mfa/totp/confirm [post]
+// @Security ApiKeyAuth
+func (uh *UserHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	if isAPIKeyRequest(r) {
+		http.Error(w, "mfa cannot be managed with an api key", http.StatusForbidden)
+		return
+	}
+
+	input := dto.TOTPCodeInput{}
+	err := json.NewDecoder(r.Body).Decode(&input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	userDB := uh.UserDB.WithContext(r.Context())
+	user, err := userDB.FindById(currentUserID(r))
+	if err != nil {
+		http.Error(w, "user not found", http.StatusUnauthorized)
+		return
+	}
+	if user.TOTPEnabled {
+		http.Error(w, "totp already enabled", http.StatusConflict)
+		return
+	}
+	if !user.ConfirmTOTP(input.Code, time.Now()) {
+		http.Error(w, "invalid code", http.StatusBadRequest)
+		return
+	}
+
+	codes, plain, err := entity.NewRecoveryCodes(user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	err = uh.RecoveryDB.WithContext(r.Context()).Replace(user.ID.String(), codes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = userDB.Update(user)
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(dto.RecoveryCodesOutput{RecoveryCodes: plain})
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"apis/internal/dto"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"time"
+)
+
+// GetSessions godoc
+// @Summary Lista as sessões ativas
+// @Description Lista as sessões ativas do usuário autenticado, indicando a sessão da requisição atual
+// @Tags users
+// @Produce json
+// @Success 200 {array} dto.SessionOutput "Sessões encontradas com sucesso"
+// @Failure 401 {object} Error "Não autenticado"
+// @Failure 403 {object} Error "Escopo insuficiente ou chave de API"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /users/me/sessions [get]
+// @Security ApiKeyAuth
+func (uh *UserHandler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	// Chaves de API não têm sessão e não podem ver nem encerrar as do dono.
+	if isAPIKeyRequest(r) {
+		http.Error(w, "sessions cannot be managed with an api key", http.StatusForbidden)
+		return
+	}
+
+	sessions, err := uh.SessionDB.WithContext(r.Context()).FindActiveByUser(currentUserID(r), time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	current := currentSessionID(r)
+	output := make([]dto.SessionOutput, 0, len(sessions))
+	for _, session := range sessions {
+		output = append(output, dto.SessionOutput{
+			ID:         session.ID.String(),
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID.String() == current,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(output)
+}
+
+// DeleteSession godoc
+// @Summary Encerra uma sessão
+// @Description Encerra uma sessão do usuário autenticado. Os tokens emitidos por ela deixam de ser aceitos.
+// @Tags users
+// @Produce json
+// @Param sessionID path string true "ID da sessão"
+// @Success 204 "Sessão encerrada com sucesso"
+// @Failure 401 {object} Error "Não autenticado"
+// @Failure 403 {object} Error "Escopo insuficiente ou chave de API"
+// @Failure 404 {object} Error "Sessão não encontrada"
+// @Router /users/me/sessions/{sessionID} [delete]
+// @Security ApiKeyAuth
+func (uh *UserHandler) DeleteSession(w http.ResponseWriter, r *http.Request) {
+	if isAPIKeyRequest(r) {
+		http.Error(w, "sessions cannot be managed with an api key", http.StatusForbidden)
+		return
+	}
+
+	err := uh.SessionDB.WithContext(r.Context()).Revoke(currentUserID(r), chi.URLParam(r, "sessionID"), time.Now())
+	if err != nil {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteSessions godoc
+// @Summary Encerra todas as sessões
+// @Description Encerra todas as sessões do usuário autenticado, inclusive a atual
+// @Tags users
+// @Produce json
+// @Success 200 {object} dto.RevokeSessionsOutput "Sessões encerradas com sucesso"
+// @Failure 401 {object} Error "Não autenticado"
+// @Failure 403 {object} Error "Escopo insuficiente ou chave de API"
+// @Failure 500 {object} Error "Erro interno"
+// @Router /users/me/sessions [delete]
+// @Security ApiKeyAuth
+func (uh *UserHandler) DeleteSessions(w http.ResponseWriter, r *http.Request) {
+	if isAPIKeyRequest(r) {
+		http.Error(w, "sessions cannot be managed with an api key", http.StatusForbidden)
+		return
+	}
+
+	revoked, err := uh.SessionDB.WithContext(r.Context()).RevokeAll(currentUserID(r), time.Now(), "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(dto.RevokeSessionsOutput{Revoked: revoked})
+}
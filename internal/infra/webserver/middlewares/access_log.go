@@ -0,0 +1,85 @@
+package middlewares
+
+import (
+	"apis/pkg/logger"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type accessLogKey struct{}
+
+// accessLogEntry é preenchido pelos middlewares internos com dados que só
+// ficam disponíveis após a autenticação.
+type accessLogEntry struct {
+	userID string
+}
+
+// AccessLog registra uma linha por requisição e disponibiliza no contexto um
+// logger com o ID da requisição, recuperável com logger.FromContext.
+// Deve ser usado após RequestID e Tracing.
+func AccessLog(base *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			entry := &accessLogEntry{}
+			requestLogger := base.With(zap.String("request_id", middleware.GetReqID(r.Context())))
+			if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+				requestLogger = requestLogger.With(zap.String("trace_id", sc.TraceID().String()))
+			}
+
+			ctx := context.WithValue(r.Context(), accessLogKey{}, entry)
+			ctx = logger.WithContext(ctx, requestLogger)
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+
+				route := r.URL.Path
+				if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+					route = rctx.RoutePattern()
+				}
+
+				fields := []zap.Field{
+					zap.String("method", r.Method),
+					zap.String("route", route),
+					zap.String("path", r.URL.Path),
+					zap.Int("status", status),
+					zap.Int("bytes", ww.BytesWritten()),
+					zap.Duration("latency", time.Since(start)),
+					zap.String("remote_addr", r.RemoteAddr),
+					zap.String("user_agent", r.UserAgent()),
+				}
+				if entry.userID != "" {
+					fields = append(fields, zap.String("user_id", entry.userID))
+				}
+
+				switch {
+				case status >= http.StatusInternalServerError:
+					requestLogger.Error("request completed", fields...)
+				case status >= http.StatusBadRequest:
+					requestLogger.Warn("request completed", fields...)
+				default:
+					requestLogger.Info("request completed", fields...)
+				}
+			}()
+
+			next.ServeHTTP(ww, r.WithContext(ctx))
+		})
+	}
+}
+
+// setAccessLogUser informa ao AccessLog o usuário autenticado da requisição.
+func setAccessLogUser(ctx context.Context, userID string) {
+	if entry, ok := ctx.Value(accessLogKey{}).(*accessLogEntry); ok {
+		entry.userID = userID
+	}
+}
@@ -0,0 +1,79 @@
+package middlewares
+
+import (
+	"apis/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/jwtauth"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = middleware.GetReqID(r.Context())
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(RequestIDHeader, "client-id-1")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, "client-id-1", seen, "incoming request ID should be propagated")
+	assert.Equal(t, "client-id-1", w.Header().Get(RequestIDHeader), "request ID should be returned")
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(RequestIDHeader, "bad id\n")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.NotEqual(t, "bad id\n", seen, "invalid request ID should be replaced")
+	assert.Len(t, w.Header().Get(RequestIDHeader), 36, "generated request ID should be a UUID")
+}
+
+func TestAccessLog(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	ja := jwtauth.New("HS256", []byte("secret"), nil)
+	_, token, _ := ja.Encode(map[string]interface{}{"sub": "user-1"})
+
+	r := chi.NewRouter()
+	r.Use(RequestID)
+	r.Use(AccessLog(zap.New(core)))
+	r.Group(func(r chi.Router) {
+		r.Use(jwtauth.Verifier(ja))
+		r.Use(Actor)
+		r.Get("/products/{id}", func(w http.ResponseWriter, r *http.Request) {
+			logger.FromContext(r.Context()).Info("inside handler")
+			w.WriteHeader(http.StatusTeapot)
+			_, _ = w.Write([]byte("body"))
+		})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/products/42", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	req.Header.Set("Authorization", "Bearer "+token)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logs.All()
+	assert.Equal(t, 2, len(entries), "handler and access log lines should be written")
+
+	handlerLog := entries[0].ContextMap()
+	assert.Equal(t, "req-1", handlerLog["request_id"], "handler logger should carry the request ID")
+	assert.Equal(t, "user-1", handlerLog["user_id"], "handler logger should carry the user")
+
+	access := entries[1]
+	fields := access.ContextMap()
+	assert.Equal(t, "request completed", access.Message)
+	assert.Equal(t, zap.WarnLevel, access.Level, "4xx should be logged as warning")
+	assert.Equal(t, "GET", fields["method"])
+	assert.Equal(t, "/products/{id}", fields["route"], "route pattern should be logged")
+	assert.Equal(t, int64(http.StatusTeapot), fields["status"])
+	assert.Equal(t, int64(4), fields["bytes"])
+	assert.Equal(t, "req-1", fields["request_id"])
+	assert.Equal(t, "user-1", fields["user_id"], "user from the JWT should be logged")
+	assert.Contains(t, fields, "latency")
+}
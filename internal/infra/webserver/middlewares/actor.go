@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"apis/internal/infra/database"
+	"apis/pkg/logger"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Actor identifica o usuário autenticado da requisição para a auditoria gravada
+// pelos repositórios, para o logger da requisição, para o AccessLog e para o
+// span da requisição.
+// Deve ser usado após Authenticate, que põe no contexto o token do JWT ou da
+// chave de API. Nas rotas públicas a requisição fica sem autor.
+func Actor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := subjectFromContext(ctx)
+
+		ctx = database.ContextWithAudit(ctx, database.AuditMetadata{
+			Actor:     userID,
+			RequestID: middleware.GetReqID(ctx),
+		})
+		if userID != "" {
+			setAccessLogUser(ctx, userID)
+			trace.SpanFromContext(ctx).SetAttributes(attribute.String("enduser.id", userID))
+			ctx = logger.WithContext(ctx, logger.FromContext(ctx).With(zap.String("user_id", userID)))
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
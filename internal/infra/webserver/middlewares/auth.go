@@ -0,0 +1,54 @@
+package middlewares
+
+import (
+	"apis/internal/entity"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/jwtauth"
+)
+
+// RequireRole permite a requisição somente quando o token possui o papel informado.
+// Deve ser usado após Authenticate, que põe na claim role o papel atual do
+// usuário em vez do papel da emissão do token.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if claimFromContext(r.Context(), "role") != role {
+				writeError(w, http.StatusForbidden, "forbidden")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireScope permite a requisição somente quando a claim scope do token
+// contém o escopo informado. Caso contrário responde 403 com o erro
+// insufficient_scope da RFC 6750.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !entity.ParseScopes(claimFromContext(r.Context(), "scope")).Contains(scope) {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, scope))
+				writeError(w, http.StatusForbidden, "insufficient scope")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func subjectFromContext(ctx context.Context) string {
+	return claimFromContext(ctx, "sub")
+}
+
+func claimFromContext(ctx context.Context, claim string) string {
+	_, claims, err := jwtauth.FromContext(ctx)
+	if err != nil {
+		return ""
+	}
+	value, _ := claims[claim].(string)
+	return value
+}
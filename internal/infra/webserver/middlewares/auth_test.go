@@ -0,0 +1,45 @@
+package middlewares
+
+import (
+	"apis/internal/entity"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireScope(t *testing.T) {
+	ja := jwtauth.New("HS256", []byte("secret"), nil)
+	handler := jwtauth.Verifier(ja)(RequireScope(entity.ScopeProductsWrite)(okHandler()))
+
+	tests := []struct {
+		name   string
+		scope  interface{}
+		status int
+	}{
+		{"granted", "products:read products:write", http.StatusOK},
+		{"missing scope", "products:read", http.StatusForbidden},
+		{"no scope claim", nil, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := map[string]interface{}{"sub": "user-1"}
+			if tt.scope != nil {
+				claims["scope"] = tt.scope
+			}
+			_, token, _ := ja.Encode(claims)
+
+			r := httptest.NewRequest(http.MethodPost, "/products", nil)
+			r.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusForbidden {
+				assert.Equal(t, `Bearer error="insufficient_scope", scope="products:write"`, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package middlewares
+
+import (
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"apis/pkg/logger"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/lestrrat-go/jwx/jwt"
+	"go.uber.org/zap"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// Authenticate aceita um JWT no header Authorization ou uma chave de API no
+// header X-API-Key. A chave é convertida num token com as mesmas claims de
+// um JWT do usuário, para que os middlewares e handlers seguintes não
+// precisem distinguir as duas formas. Tokens ligados a uma sessão encerrada
+// ou a uma conta que não está ativa são recusados, e o papel e os escopos do
+// token passam a refletir o cadastro atual do usuário. Tokens de clientes
+// OAuth removidos também são recusados. O tenant da claim
+// tenant_id, ou defaultTenantID nos tokens sem ela, restringe as consultas
+// seguintes. Substitui jwtauth.Verifier e jwtauth.Authenticator.
+func Authenticate(ja *jwtauth.JWTAuth, apiKeys database.APIKeyInterface, users database.UserInterface, sessions database.SessionInterface, clients database.OAuthClientInterface, defaultTenantID entitypkg.ID) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var token jwt.Token
+			var err error
+			key := r.Header.Get(APIKeyHeader)
+			if key != "" {
+				token, err = verifyAPIKey(r, key, apiKeys, users)
+			} else {
+				token, err = jwtauth.VerifyRequest(ja, r, jwtauth.TokenFromHeader, jwtauth.TokenFromCookie)
+			}
+			if err == nil && token != nil {
+				var tenantID entitypkg.ID
+				if tenantID, err = tokenTenant(token, defaultTenantID); err == nil {
+					r = r.WithContext(database.ContextWithTenant(r.Context(), tenantID))
+					if key == "" {
+						err = verifySession(r, token, sessions, users, clients)
+					}
+				}
+			}
+			if err != nil || token == nil {
+				writeError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(jwtauth.NewContext(r.Context(), token, nil)))
+		})
+	}
+}
+
+// tokenTenant lê a claim tenant_id. Tokens emitidos antes dos tenants não a
+// possuem e pertencem ao tenant padrão.
+func tokenTenant(token jwt.Token, defaultTenantID entitypkg.ID) (entitypkg.ID, error) {
+	value, ok := token.Get(entity.TenantIDClaim)
+	if !ok {
+		return defaultTenantID, nil
+	}
+	tenantID, _ := value.(string)
+	id, err := entitypkg.ParseID(tenantID)
+	if err != nil {
+		return entitypkg.ID{}, jwtauth.ErrUnauthorized
+	}
+	return id, nil
+}
+
+// verifySession recusa tokens de sessões encerradas ou expiradas e de contas
+// suspensas ou desativadas. O papel e os escopos do token são trocados pelos
+// do cadastro atual, para que um administrador rebaixado perca o acesso sem
+// esperar o token expirar. Somente os tokens de client_credentials, cujo sub
+// é o próprio cliente, não têm sessão e são conferidos em verifyClient.
+// Tokens de usuário sem a claim sid,
+// emitidos antes das sessões, são recusados para que não escapem do
+// encerramento das sessões nem da suspensão da conta.
+func verifySession(r *http.Request, token jwt.Token, sessions database.SessionInterface, users database.UserInterface, clients database.OAuthClientInterface) error {
+	value, ok := token.Get(entity.SessionIDClaim)
+	if !ok {
+		if clientID, _ := token.Get("client_id"); clientID != nil && clientID == token.Subject() {
+			return verifyClient(r, token, clients)
+		}
+		return jwtauth.ErrUnauthorized
+	}
+	sid, _ := value.(string)
+	session, err := sessions.WithContext(r.Context()).FindByID(sid)
+	if err != nil {
+		return jwtauth.ErrUnauthorized
+	}
+	now := time.Now()
+	if !session.IsActive(now) || session.UserID.String() != token.Subject() {
+		return jwtauth.ErrUnauthorized
+	}
+	user, err := users.WithContext(r.Context()).FindById(session.UserID.String())
+	if err != nil || !user.IsActive() {
+		return jwtauth.ErrUnauthorized
+	}
+	scope, _ := token.Get("scope")
+	scopes, _ := scope.(string)
+	if err := token.Set("role", user.Role); err != nil {
+		return err
+	}
+	if err := token.Set("scope", entity.ParseScopes(scopes).Intersect(user.AllowedScopes()).String()); err != nil {
+		return err
+	}
+
+	if session.ShouldTouch(now) {
+		if err := sessions.WithContext(r.Context()).Touch(session, now); err != nil {
+			logger.FromContext(r.Context()).Warn("failed to record session activity", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// verifyClient recusa tokens de client_credentials de clientes removidos ou
+// emitidos antes do cadastro atual do cliente. Os escopos que o cliente
+// perdeu depois da emissão não são concedidos.
+func verifyClient(r *http.Request, token jwt.Token, clients database.OAuthClientInterface) error {
+	client, err := clients.WithContext(r.Context()).FindByClientID(token.Subject())
+	if err != nil {
+		return jwtauth.ErrUnauthorized
+	}
+	// iat tem precisão de segundos.
+	if token.IssuedAt().Before(client.CreatedAt.Truncate(time.Second)) {
+		return jwtauth.ErrUnauthorized
+	}
+	scope, _ := token.Get("scope")
+	scopes, _ := scope.(string)
+	return token.Set("scope", entity.ParseScopes(scopes).Intersect(client.Scopes).String())
+}
+
+func verifyAPIKey(r *http.Request, key string, apiKeys database.APIKeyInterface, users database.UserInterface) (jwt.Token, error) {
+	prefix, ok := entity.ParseAPIKeyPrefix(key)
+	if !ok {
+		return nil, jwtauth.ErrUnauthorized
+	}
+	apiKey, err := apiKeys.WithContext(r.Context()).FindByPrefix(prefix)
+	if err != nil {
+		return nil, jwtauth.ErrUnauthorized
+	}
+	now := time.Now()
+	if !apiKey.Matches(key) || apiKey.IsExpired(now) {
+		return nil, jwtauth.ErrUnauthorized
+	}
+	// A chave não identifica o tenant: ele é o do dono da chave.
+	user, err := users.WithContext(r.Context()).FindByIdAcrossTenants(apiKey.UserID.String())
+	if err != nil || !user.IsActive() {
+		return nil, jwtauth.ErrUnauthorized
+	}
+
+	if apiKey.ShouldTouch(now) {
+		if err := apiKeys.WithContext(r.Context()).Touch(apiKey, now); err != nil {
+			logger.FromContext(r.Context()).Warn("failed to record api key usage", zap.Error(err))
+		}
+	}
+
+	// Escopos que o usuário perdeu depois de criar a chave não são concedidos.
+	token := jwt.New()
+	claims := map[string]interface{}{
+		jwt.SubjectKey:       user.ID.String(),
+		entity.TenantIDClaim: user.TenantID.String(),
+		"name":               user.Name,
+		"email":              user.Email,
+		"role":               user.Role,
+		"scope":              apiKey.Scopes.Intersect(user.AllowedScopes()).String(),
+		entity.APIKeyIDClaim: apiKey.ID.String(),
+	}
+	for claim, value := range claims {
+		if err := token.Set(claim, value); err != nil {
+			return nil, err
+		}
+	}
+	return token, nil
+}
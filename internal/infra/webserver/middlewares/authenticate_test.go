@@ -0,0 +1,221 @@
+package middlewares
+
+import (
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newAuthenticateDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&entity.User{}, &entity.APIKey{}, &entity.Session{}, &entity.Tenant{}, &entity.OAuthClient{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func authenticateRequest(handler http.Handler, header, value string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/products", nil)
+	if header != "" {
+		r.Header.Set(header, value)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestAuthenticate(t *testing.T) {
+	db := newAuthenticateDB(t)
+	ja := jwtauth.New("HS256", []byte("secret"), nil)
+	apiKeys := database.NewAPIKey(db)
+	sessions := database.NewSession(db)
+
+	tenant, err := entity.NewTenant("Default", entity.DefaultTenantSlug)
+	assert.Nil(t, err)
+	other, err := entity.NewTenant("Acme", "acme")
+	assert.Nil(t, err)
+
+	user, err := entity.NewUser("John", "john@example.com", "123456")
+	assert.Nil(t, err)
+	user.TenantID = tenant.ID
+	assert.Nil(t, db.Create(user).Error)
+
+	apiKey, key, err := entity.NewAPIKey(user.ID, "ci", entity.Scopes{entity.ScopeProductsRead}, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, apiKeys.Create(apiKey))
+
+	past := time.Now().Add(-time.Hour)
+	expired, expiredKey, err := entity.NewAPIKey(user.ID, "old", entity.Scopes{entity.ScopeProductsRead}, &past)
+	assert.Nil(t, err)
+	assert.Nil(t, apiKeys.Create(expired))
+
+	var claims map[string]interface{}
+	var tenantID entitypkg.ID
+	handler := Authenticate(ja, apiKeys, database.NewUser(db), sessions, database.NewOAuthClient(db), tenant.ID)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, claims, _ = jwtauth.FromContext(r.Context())
+		tenantID = database.TenantFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	w := authenticateRequest(handler, "", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "request without credentials should be rejected")
+
+	session := entity.NewSession(user.ID, "curl", "10.0.0.1", time.Hour)
+	assert.Nil(t, sessions.Create(session))
+	_, token, _ := ja.Encode(map[string]interface{}{"sub": user.ID.String(), entity.SessionIDClaim: session.ID.String()})
+	claims = nil
+	w = authenticateRequest(handler, "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusOK, w.Code, "valid JWT should be accepted")
+	assert.Equal(t, user.ID.String(), claims["sub"])
+	assert.NotContains(t, claims, entity.APIKeyIDClaim)
+	assert.Equal(t, tenant.ID, tenantID, "token without tenant claim should use the default tenant")
+
+	_, token, _ = ja.Encode(map[string]interface{}{"sub": user.ID.String()})
+	w = authenticateRequest(handler, "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "user token without session should be rejected")
+
+	_, token, _ = ja.Encode(map[string]interface{}{"sub": user.ID.String(), "client_id": "billing"})
+	w = authenticateRequest(handler, "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "oauth user token without session should be rejected")
+
+	clients := database.NewOAuthClient(db).WithContext(database.ContextWithTenant(context.Background(), tenant.ID))
+	client, _, err := entity.NewOAuthClient("billing", entity.Scopes{entity.ScopeProductsRead})
+	assert.Nil(t, err)
+	assert.Nil(t, clients.Create(client))
+	clientClaims := map[string]interface{}{"sub": client.ClientID, "client_id": client.ClientID, "iat": time.Now().Unix(), "scope": "products:read products:write"}
+	_, token, _ = ja.Encode(clientClaims)
+	claims = nil
+	w = authenticateRequest(handler, "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusOK, w.Code, "client_credentials token should be accepted without session")
+	assert.Equal(t, entity.ScopeProductsRead, claims["scope"], "scopes the client lost should not be granted")
+
+	clientClaims["iat"] = client.CreatedAt.Add(-time.Hour).Unix()
+	_, staleToken, _ := ja.Encode(clientClaims)
+	w = authenticateRequest(handler, "Authorization", "Bearer "+staleToken)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "client token issued before the client should be rejected")
+
+	assert.Nil(t, clients.Delete(client.ID.String()))
+	w = authenticateRequest(handler, "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "token of a deleted client should be rejected")
+
+	claims = nil
+	w = authenticateRequest(handler, APIKeyHeader, key)
+	assert.Equal(t, http.StatusOK, w.Code, "valid api key should be accepted")
+	assert.Equal(t, user.ID.String(), claims["sub"])
+	assert.Equal(t, user.Email, claims["email"])
+	assert.Equal(t, entity.ScopeProductsRead, claims["scope"])
+	assert.Equal(t, apiKey.ID.String(), claims[entity.APIKeyIDClaim])
+	assert.Equal(t, tenant.ID.String(), claims[entity.TenantIDClaim])
+
+	found, err := apiKeys.FindByPrefix(apiKey.Prefix)
+	assert.Nil(t, err)
+	assert.NotNil(t, found.LastUsedAt, "api key usage should be recorded")
+
+	w = authenticateRequest(handler, APIKeyHeader, key+"x")
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "wrong api key should be rejected")
+
+	w = authenticateRequest(handler, APIKeyHeader, expiredKey)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "expired api key should be rejected")
+
+	w = authenticateRequest(handler, APIKeyHeader, "not-a-key")
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "malformed api key should be rejected")
+
+	session = entity.NewSession(user.ID, "curl", "10.0.0.1", time.Hour)
+	assert.Nil(t, sessions.Create(session))
+	_, token, _ = ja.Encode(map[string]interface{}{"sub": user.ID.String(), entity.SessionIDClaim: session.ID.String()})
+	w = authenticateRequest(handler, "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusOK, w.Code, "token of an active session should be accepted")
+
+	assert.Nil(t, sessions.Revoke(user.ID.String(), session.ID.String(), time.Now()))
+	w = authenticateRequest(handler, "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "token of a revoked session should be rejected")
+
+	_, token, _ = ja.Encode(map[string]interface{}{"sub": user.ID.String(), entity.SessionIDClaim: "unknown"})
+	w = authenticateRequest(handler, "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "token of an unknown session should be rejected")
+
+	session = entity.NewSession(user.ID, "curl", "10.0.0.1", time.Hour)
+	assert.Nil(t, sessions.Create(session))
+	_, token, _ = ja.Encode(map[string]interface{}{"sub": user.ID.String(), entity.SessionIDClaim: session.ID.String(), entity.TenantIDClaim: other.ID.String()})
+	w = authenticateRequest(handler, "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "token of another tenant should be rejected")
+
+	_, token, _ = ja.Encode(map[string]interface{}{"sub": user.ID.String(), entity.TenantIDClaim: "invalid"})
+	w = authenticateRequest(handler, "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "token with an invalid tenant should be rejected")
+
+	session = entity.NewSession(user.ID, "curl", "10.0.0.1", time.Hour)
+	assert.Nil(t, sessions.Create(session))
+	_, token, _ = ja.Encode(map[string]interface{}{"sub": user.ID.String(), entity.SessionIDClaim: session.ID.String()})
+	assert.Nil(t, user.ChangeStatus(entity.UserStatusSuspended, "", time.Now()))
+	assert.Nil(t, db.Save(user).Error)
+	w = authenticateRequest(handler, "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "token of a suspended user should be rejected")
+	w = authenticateRequest(handler, APIKeyHeader, key)
+	assert.Equal(t, http.StatusUnauthorized, w.Code, "api key of a suspended user should be rejected")
+
+	assert.Nil(t, user.ChangeStatus(entity.UserStatusActive, "", time.Now()))
+	assert.Nil(t, db.Save(user).Error)
+	w = authenticateRequest(handler, "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusOK, w.Code, "token of a reactivated user should be accepted")
+}
+
+func TestAuthenticate_CurrentRole(t *testing.T) {
+	db := newAuthenticateDB(t)
+	ja := jwtauth.New("HS256", []byte("secret"), nil)
+	sessions := database.NewSession(db)
+
+	tenant, err := entity.NewTenant("Default", entity.DefaultTenantSlug)
+	assert.Nil(t, err)
+	user, err := entity.NewUser("John", "john@example.com", "123456")
+	assert.Nil(t, err)
+	user.TenantID = tenant.ID
+	user.Role = entity.RoleAdmin
+	assert.Nil(t, db.Create(user).Error)
+
+	session := entity.NewSession(user.ID, "curl", "10.0.0.1", time.Hour)
+	assert.Nil(t, sessions.Create(session))
+	_, token, _ := ja.Encode(map[string]interface{}{
+		"sub":                 user.ID.String(),
+		"role":                entity.RoleAdmin,
+		"scope":               entity.AllScopes.String(),
+		entity.SessionIDClaim: session.ID.String(),
+	})
+
+	authenticate := Authenticate(ja, database.NewAPIKey(db), database.NewUser(db), sessions, database.NewOAuthClient(db), tenant.ID)
+	handler := authenticate(RequireScope(entity.ScopeUsersAdmin)(RequireRole(entity.RoleAdmin)(okHandler())))
+
+	w := authenticateRequest(handler, "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusOK, w.Code, "admin token should be accepted")
+
+	user.Role = entity.RoleUser
+	assert.Nil(t, db.Save(user).Error)
+	w = authenticateRequest(handler, "Authorization", "Bearer "+token)
+	assert.Equal(t, http.StatusForbidden, w.Code, "demoted admin should lose admin access before the token expires")
+
+	var claims map[string]interface{}
+	handler = authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, claims, _ = jwtauth.FromContext(r.Context())
+	}))
+	authenticateRequest(handler, "Authorization", "Bearer "+token)
+	assert.Equal(t, entity.RoleUser, claims["role"])
+	assert.NotContains(t, claims["scope"], entity.ScopeUsersAdmin, "scopes the user lost should not be granted")
+}
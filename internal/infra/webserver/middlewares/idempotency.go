@@ -0,0 +1,191 @@
+package middlewares
+
+import (
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	"apis/internal/infra/webserver/handlers"
+	entitypkg "apis/pkg/entity"
+	"apis/pkg/logger"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	DefaultIdempotencyTTL     = 24 * time.Hour
+	DefaultIdempotencyLock    = time.Minute
+	DefaultIdempotencyCleanup = time.Hour
+	maxIdempotencyKeyLength   = 255
+	idempotencyReserveRetries = 2
+)
+
+// Idempotency guarda a primeira resposta de cada requisição enviada com o
+// header Idempotency-Key e a devolve nas repetições com o mesmo corpo.
+// A chave é reutilizável após ttl. Respostas 5xx não são guardadas para
+// permitir que o cliente tente novamente. Uma requisição em andamento segura a
+// chave por lock; depois disso, como quando o processo cai, outra a assume.
+func Idempotency(store database.IdempotencyInterface, ttl, lock time.Duration) func(http.Handler) http.Handler {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	if lock <= 0 {
+		lock = DefaultIdempotencyLock
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				writeError(w, http.StatusBadRequest, "idempotency key is too long")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scopedKey := idempotencyScopedKey(r, key)
+			fingerprint := idempotencyFingerprint(r, body)
+
+			for attempt := 0; attempt < idempotencyReserveRetries; attempt++ {
+				record := entity.NewIdempotencyRecord(scopedKey, fingerprint, lock)
+				reserved, err := store.Reserve(record)
+				if err != nil {
+					writeError(w, http.StatusInternalServerError, err.Error())
+					return
+				}
+				if reserved {
+					serveAndStore(store, record, ttl, next, w, r)
+					return
+				}
+
+				existing, err := store.FindByKey(scopedKey)
+				if err != nil {
+					// O registro foi removido entre a reserva e a leitura.
+					continue
+				}
+				if existing.IsExpired(time.Now()) {
+					if _, err := store.DeleteIfExpired(scopedKey, time.Now()); err != nil {
+						writeError(w, http.StatusInternalServerError, err.Error())
+						return
+					}
+					continue
+				}
+				if !existing.Matches(fingerprint) {
+					writeError(w, http.StatusUnprocessableEntity, "idempotency key reused with a different request")
+					return
+				}
+				if !existing.Completed {
+					w.Header().Set("Retry-After", "1")
+					writeError(w, http.StatusConflict, "a request with this idempotency key is already in progress")
+					return
+				}
+
+				if existing.ContentType != "" {
+					w.Header().Set("Content-Type", existing.ContentType)
+				}
+				w.Header().Set(IdempotentReplayedHeader, "true")
+				w.WriteHeader(existing.StatusCode)
+				_, _ = w.Write(existing.Body)
+				return
+			}
+
+			w.Header().Set("Retry-After", "1")
+			writeError(w, http.StatusConflict, "a request with this idempotency key is already in progress")
+		})
+	}
+}
+
+// CleanupIdempotency remove as chaves expiradas a cada interval até ctx ser
+// cancelado. Sem ela, uma chave expirada só é apagada quando reutilizada.
+func CleanupIdempotency(ctx context.Context, store database.IdempotencyInterface, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultIdempotencyCleanup
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := store.DeleteExpired(now); err != nil {
+				logger.FromContext(ctx).Error("failed to delete expired idempotency keys", zap.Error(err))
+			}
+		}
+	}
+}
+
+func serveAndStore(store database.IdempotencyInterface, record *entity.IdempotencyRecord, ttl time.Duration, next http.Handler, w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+	ww.Tee(&buf)
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			_ = store.Delete(record.Key)
+			panic(rec)
+		}
+	}()
+
+	next.ServeHTTP(ww, r)
+
+	status := ww.Status()
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if status >= http.StatusInternalServerError {
+		if err := store.Delete(record.Key); err != nil {
+			logger.FromContext(r.Context()).Error("failed to release idempotency key", zap.Error(err))
+		}
+		return
+	}
+
+	record.Complete(status, ww.Header().Get("Content-Type"), buf.Bytes(), ttl)
+	if err := store.Complete(record); err != nil {
+		logger.FromContext(r.Context()).Error("failed to store idempotent response", zap.Error(err))
+	}
+}
+
+// idempotencyScopedKey isola a chave por rota e, quando autenticado, por
+// usuário. Nas rotas públicas o tenant impede que lojas diferentes vejam a
+// resposta umas das outras.
+func idempotencyScopedKey(r *http.Request, key string) string {
+	subject := subjectFromContext(r.Context())
+	if tenantID := database.TenantFromContext(r.Context()); tenantID != (entitypkg.ID{}) {
+		subject = tenantID.String() + "/" + subject
+	}
+	sum := sha256.Sum256([]byte(r.Method + "\n" + r.URL.Path + "\n" + subject + "\n" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+func idempotencyFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + "\n" + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(handlers.Error{Message: message})
+}
@@ -0,0 +1,193 @@
+package middlewares
+
+import (
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newIdempotencyStore(t *testing.T) database.IdempotencyInterface {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&entity.IdempotencyRecord{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+	return database.NewIdempotency(db)
+}
+
+func postWithKey(handler http.Handler, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(body))
+	r.Header.Set(IdempotencyKeyHeader, key)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestIdempotency_ReplaysFirstResponse(t *testing.T) {
+	var calls int32
+	handler := Idempotency(newIdempotencyStore(t), time.Hour, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprintf(w, `{"call":%d}`, n)
+	}))
+
+	first := postWithKey(handler, "abc", `{"name":"p"}`)
+	second := postWithKey(handler, "abc", `{"name":"p"}`)
+
+	assert.Equal(t, int32(1), calls, "handler should run once")
+	assert.Equal(t, http.StatusCreated, second.Code, "status should be replayed")
+	assert.Equal(t, first.Body.String(), second.Body.String(), "body should be replayed")
+	assert.Equal(t, "true", second.Header().Get(IdempotentReplayedHeader), "replay should be flagged")
+	assert.Equal(t, "application/json", second.Header().Get("Content-Type"), "content type should be replayed")
+}
+
+func TestIdempotency_RejectsDifferentBody(t *testing.T) {
+	handler := Idempotency(newIdempotencyStore(t), time.Hour, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	postWithKey(handler, "abc", `{"name":"p"}`)
+	w := postWithKey(handler, "abc", `{"name":"other"}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "reused key should be rejected")
+}
+
+func TestIdempotency_DoesNotStoreServerErrors(t *testing.T) {
+	var calls int32
+	handler := Idempotency(newIdempotencyStore(t), time.Hour, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	first := postWithKey(handler, "abc", `{}`)
+	second := postWithKey(handler, "abc", `{}`)
+
+	assert.Equal(t, http.StatusInternalServerError, first.Code)
+	assert.Equal(t, http.StatusCreated, second.Code, "request should be retried after a server error")
+	assert.Equal(t, int32(2), calls)
+}
+
+func TestIdempotency_ConcurrentDuplicates(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	handler := Idempotency(newIdempotencyStore(t), time.Hour, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		postWithKey(handler, "abc", `{}`)
+	}()
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second, time.Millisecond)
+	w := postWithKey(handler, "abc", `{}`)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, http.StatusConflict, w.Code, "duplicate in flight should be rejected")
+	assert.Equal(t, int32(1), calls, "handler should run once")
+}
+
+func TestIdempotency_WithoutKey(t *testing.T) {
+	var calls int32
+	handler := Idempotency(newIdempotencyStore(t), time.Hour, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+
+	for i := 0; i < 2; i++ {
+		r := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(`{}`))
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	assert.Equal(t, int32(2), calls, "requests without key should not be deduplicated")
+}
+
+func TestIdempotency_AbandonedReservation(t *testing.T) {
+	store := newIdempotencyStore(t)
+	var calls int32
+	handler := Idempotency(store, time.Hour, time.Minute)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	reserve := func(key string, lock time.Duration) {
+		r := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(`{}`))
+		record := entity.NewIdempotencyRecord(idempotencyScopedKey(r, key), idempotencyFingerprint(r, []byte(`{}`)), lock)
+		reserved, err := store.Reserve(record)
+		assert.Nil(t, err)
+		assert.True(t, reserved)
+	}
+
+	reserve("running", time.Minute)
+	w := postWithKey(handler, "running", `{}`)
+	assert.Equal(t, http.StatusConflict, w.Code, "reservation within the lock should be kept")
+
+	reserve("crashed", -time.Second)
+	w = postWithKey(handler, "crashed", `{}`)
+	assert.Equal(t, http.StatusCreated, w.Code, "reservation past the lock should be taken over")
+	assert.Equal(t, int32(1), calls)
+
+	record, err := store.FindByKey(idempotencyScopedKey(httptest.NewRequest(http.MethodPost, "/products", nil), "crashed"))
+	assert.Nil(t, err)
+	assert.True(t, record.Completed)
+	assert.WithinDuration(t, record.CreatedAt.Add(time.Hour), record.ExpiresAt, time.Second, "completed response should be kept for the ttl")
+}
+
+func TestCleanupIdempotency(t *testing.T) {
+	store := newIdempotencyStore(t)
+	expired := entity.NewIdempotencyRecord("expired", "fp", -time.Minute)
+	live := entity.NewIdempotencyRecord("live", "fp", time.Hour)
+	for _, record := range []*entity.IdempotencyRecord{expired, live} {
+		reserved, err := store.Reserve(record)
+		assert.Nil(t, err)
+		assert.True(t, reserved)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		CleanupIdempotency(ctx, store, 10*time.Millisecond)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		_, err := store.FindByKey("expired")
+		return err != nil
+	}, time.Second, 10*time.Millisecond, "expired key should be deleted")
+	_, err := store.FindByKey("live")
+	assert.Nil(t, err, "live key should be kept")
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("cleanup should stop when the context is cancelled")
+	}
+}
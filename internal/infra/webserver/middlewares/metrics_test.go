@@ -0,0 +1,29 @@
+package middlewares
+
+import (
+	"apis/internal/infra/metrics"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Metrics)
+	r.Get("/products/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	counter := metrics.HTTPRequestsTotal.WithLabelValues(http.MethodGet, "/products/{id}", "404")
+	before := testutil.ToFloat64(counter)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/products/1", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/products/2", nil))
+
+	assert.Equal(t, before+2, testutil.ToFloat64(counter), "requests should be counted by route pattern")
+	assert.Equal(t, 1, testutil.CollectAndCount(metrics.HTTPRequestDuration, "products_api_http_request_duration_seconds"), "latency should be observed")
+}
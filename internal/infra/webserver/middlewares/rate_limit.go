@@ -0,0 +1,76 @@
+package middlewares
+
+import (
+	"apis/internal/infra/ratelimit"
+	"apis/pkg/logger"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RateLimitPolicyHeader    = "RateLimit-Policy"
+	RetryAfterHeader         = "Retry-After"
+)
+
+// RateLimit limita as requisições do grupo de rotas name com um token bucket
+// por cliente: o sub do JWT quando a requisição está autenticada, ou o IP
+// nas rotas anônimas. Em rotas autenticadas deve ser usado após
+// jwtauth.Verifier. Falhas do store não bloqueiam a requisição.
+func RateLimit(store ratelimit.Store, name string, limit ratelimit.Limit) func(http.Handler) http.Handler {
+	policy := fmt.Sprintf("%d;w=%d", limit.Requests, int(limit.Period.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		if !limit.Enabled() {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := store.Take(r.Context(), rateLimitKey(r, name), limit)
+			if err != nil {
+				logger.FromContext(r.Context()).Warn("rate limit store failed", zap.Error(err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set(RateLimitLimitHeader, strconv.Itoa(result.Limit))
+			w.Header().Set(RateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+			w.Header().Set(RateLimitResetHeader, ceilSeconds(result.Reset))
+			w.Header().Set(RateLimitPolicyHeader, policy)
+
+			if !result.Allowed {
+				w.Header().Set(RetryAfterHeader, ceilSeconds(result.RetryAfter))
+				writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func rateLimitKey(r *http.Request, name string) string {
+	if sub := subjectFromContext(r.Context()); sub != "" {
+		return name + ":user:" + sub
+	}
+	return name + ":ip:" + clientIP(r)
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
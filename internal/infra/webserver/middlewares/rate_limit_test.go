@@ -0,0 +1,73 @@
+package middlewares
+
+import (
+	"apis/internal/infra/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitByIP(t *testing.T) {
+	handler := RateLimit(ratelimit.NewMemoryStore(), "auth", ratelimit.Limit{Requests: 2, Period: time.Minute})(okHandler())
+
+	request := func(addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/users/auth/generate_token", nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := request("10.0.0.1:1000")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get(RateLimitLimitHeader))
+	assert.Equal(t, "1", rec.Header().Get(RateLimitRemainingHeader))
+	assert.Equal(t, "2;w=60", rec.Header().Get(RateLimitPolicyHeader))
+
+	request("10.0.0.1:1001")
+	rec = request("10.0.0.1:1002")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code, "third request from the same IP should be limited")
+	assert.Equal(t, "30", rec.Header().Get(RetryAfterHeader))
+
+	rec = request("10.0.0.2:1000")
+	assert.Equal(t, http.StatusOK, rec.Code, "other IPs should not be limited")
+}
+
+func TestRateLimitBySubject(t *testing.T) {
+	jwt := jwtauth.New("HS256", []byte("secret"), nil)
+	handler := jwtauth.Verifier(jwt)(
+		RateLimit(ratelimit.NewMemoryStore(), "products", ratelimit.Limit{Requests: 1, Period: time.Minute})(okHandler()),
+	)
+
+	request := func(sub string) int {
+		_, token, _ := jwt.Encode(map[string]interface{}{"sub": sub})
+		req := httptest.NewRequest(http.MethodGet, "/products", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("user-1"))
+	assert.Equal(t, http.StatusTooManyRequests, request("user-1"), "second request from the same user should be limited")
+	assert.Equal(t, http.StatusOK, request("user-2"), "users behind the same IP should have separate buckets")
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	handler := RateLimit(ratelimit.NewMemoryStore(), "auth", ratelimit.Limit{})(okHandler())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get(RateLimitLimitHeader), "disabled limit should not set headers")
+}
+
+func okHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"net/http"
+)
+
+// TenantHeader escolhe, pelo slug, o tenant das rotas sem autenticação.
+const TenantHeader = "X-Tenant"
+
+// ResolveTenant define o tenant das rotas públicas, como cadastro e login, a
+// partir do header X-Tenant. Sem o header vale o tenant padrão. Nas rotas
+// autenticadas o tenant vem do token, em Authenticate.
+func ResolveTenant(tenants database.TenantInterface, defaultTenantID entitypkg.ID) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenantID := defaultTenantID
+			if slug := r.Header.Get(TenantHeader); slug != "" {
+				tenant, err := tenants.WithContext(r.Context()).FindBySlug(slug)
+				if err != nil {
+					writeError(w, http.StatusBadRequest, "unknown tenant")
+					return
+				}
+				tenantID = tenant.ID
+			}
+			next.ServeHTTP(w, r.WithContext(database.ContextWithTenant(r.Context(), tenantID)))
+		})
+	}
+}
+
+// RequireTenant permite a requisição somente para tokens do tenant informado.
+// Deve ser usado após Authenticate.
+func RequireTenant(tenantID entitypkg.ID) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if database.TenantFromContext(r.Context()) != tenantID {
+				writeError(w, http.StatusForbidden, "forbidden")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package middlewares
+
+import (
+	"apis/internal/entity"
+	"apis/internal/infra/database"
+	entitypkg "apis/pkg/entity"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveTenant(t *testing.T) {
+	db := newAuthenticateDB(t)
+	tenants := database.NewTenant(db)
+
+	defaultTenant, err := entity.NewTenant("Default", entity.DefaultTenantSlug)
+	assert.Nil(t, err)
+	assert.Nil(t, tenants.Create(defaultTenant))
+	acme, err := entity.NewTenant("Acme", "acme")
+	assert.Nil(t, err)
+	assert.Nil(t, tenants.Create(acme))
+
+	var tenantID entitypkg.ID
+	handler := ResolveTenant(tenants, defaultTenant.ID)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID = database.TenantFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	w := authenticateRequest(handler, "", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, defaultTenant.ID, tenantID, "request without header should use the default tenant")
+
+	w = authenticateRequest(handler, TenantHeader, "acme")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, acme.ID, tenantID, "header should select the tenant by slug")
+
+	w = authenticateRequest(handler, TenantHeader, "unknown")
+	assert.Equal(t, http.StatusBadRequest, w.Code, "unknown tenant should be rejected")
+}
+
+func TestRequireTenant(t *testing.T) {
+	tenantID := entitypkg.NewID()
+	handler := RequireTenant(tenantID)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/tenants", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r.WithContext(database.ContextWithTenant(r.Context(), tenantID)))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r.WithContext(database.ContextWithTenant(r.Context(), entitypkg.NewID())))
+	assert.Equal(t, http.StatusForbidden, w.Code, "token of another tenant should be forbidden")
+}
@@ -0,0 +1,88 @@
+package webserver
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultShutdownTimeout é usado quando ShutdownConfig.Timeout não é positivo.
+const DefaultShutdownTimeout = 10 * time.Second
+
+type ServerConfig struct {
+	Port              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+}
+
+// ShutdownConfig controla o encerramento do servidor. OnDrain é chamado antes
+// de DrainDelay para que a prontidão passe a falhar enquanto o orquestrador
+// ainda envia tráfego; depois disso as requisições em andamento têm até
+// Timeout para terminar, ou DefaultShutdownTimeout quando não configurado.
+type ShutdownConfig struct {
+	DrainDelay time.Duration
+	Timeout    time.Duration
+	OnDrain    func()
+}
+
+func NewServer(cfg ServerConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+}
+
+// Run escuta no endereço do servidor e o encerra de forma ordenada quando ctx
+// é cancelado.
+func Run(ctx context.Context, srv *http.Server, cfg ShutdownConfig) error {
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return err
+	}
+	return Serve(ctx, srv, ln, cfg)
+}
+
+// Serve atende as conexões de ln até ctx ser cancelado e então drena as
+// requisições em andamento.
+func Serve(ctx context.Context, srv *http.Server, ln net.Listener, cfg ShutdownConfig) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	if cfg.OnDrain != nil {
+		cfg.OnDrain()
+	}
+	if cfg.DrainDelay > 0 {
+		time.Sleep(cfg.DrainDelay)
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+
+	if serveErr := <-serveErr; !errors.Is(serveErr, http.ErrServerClosed) && err == nil {
+		err = serveErr
+	}
+	return err
+}
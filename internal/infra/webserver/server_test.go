@@ -0,0 +1,108 @@
+package webserver
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	srv := NewServer(ServerConfig{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte("done"))
+	}))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	drained := false
+	result := make(chan error, 1)
+	go func() {
+		result <- Serve(ctx, srv, ln, ShutdownConfig{
+			Timeout: time.Second,
+			OnDrain: func() { drained = true },
+		})
+	}()
+
+	response := make(chan string, 1)
+	go func() {
+		res, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			response <- err.Error()
+			return
+		}
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		response <- string(body)
+	}()
+
+	<-started
+	cancel()
+
+	assert.Equal(t, "done", <-response, "in-flight request should complete")
+	assert.Nil(t, <-result, "shutdown should succeed")
+	assert.True(t, drained, "OnDrain should be called")
+
+	_, err = http.Get("http://" + ln.Addr().String())
+	assert.NotNil(t, err, "new connections should be refused")
+}
+
+func TestServeShutdownTimeout(t *testing.T) {
+	started := make(chan struct{})
+	srv := NewServer(ServerConfig{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(time.Second)
+	}))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- Serve(ctx, srv, ln, ShutdownConfig{Timeout: 50 * time.Millisecond})
+	}()
+	go func() { _, _ = http.Get("http://" + ln.Addr().String()) }()
+
+	<-started
+	cancel()
+
+	assert.ErrorIs(t, <-result, context.DeadlineExceeded, "shutdown should stop waiting after the timeout")
+}
+
+func TestServeDefaultShutdownTimeout(t *testing.T) {
+	started := make(chan struct{})
+	srv := NewServer(ServerConfig{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+	}))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- Serve(ctx, srv, ln, ShutdownConfig{})
+	}()
+	go func() { _, _ = http.Get("http://" + ln.Addr().String()) }()
+
+	<-started
+	cancel()
+
+	assert.Nil(t, <-result, "shutdown without timeout should wait for in-flight requests")
+}
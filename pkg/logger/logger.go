@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+type contextKey struct{}
+
+// New cria o logger da aplicação com o nível (debug, info, warn, error) e o
+// formato (json ou console) informados. Valores vazios usam info e json.
+func New(level, format string) (*zap.Logger, error) {
+	var cfg zap.Config
+	if format == FormatConsole {
+		cfg = zap.NewDevelopmentConfig()
+	} else {
+		cfg = zap.NewProductionConfig()
+	}
+
+	if level == "" {
+		level = "info"
+	}
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Level = zap.NewAtomicLevelAt(lvl)
+
+	return cfg.Build()
+}
+
+// WithContext guarda o logger no contexto para uso por handlers e repositórios.
+func WithContext(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext devolve o logger da requisição ou o logger global quando o
+// contexto não possui um.
+func FromContext(ctx context.Context) *zap.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(contextKey{}).(*zap.Logger); ok {
+			return logger
+		}
+	}
+	return zap.L()
+}
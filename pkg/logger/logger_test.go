@@ -0,0 +1,31 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestNew(t *testing.T) {
+	logger, err := New("debug", FormatConsole)
+	assert.Nil(t, err, "error should be nil")
+	assert.True(t, logger.Core().Enabled(zap.DebugLevel), "debug should be enabled")
+
+	logger, err = New("", FormatJSON)
+	assert.Nil(t, err, "error should be nil")
+	assert.False(t, logger.Core().Enabled(zap.DebugLevel), "default level should be info")
+	assert.True(t, logger.Core().Enabled(zap.InfoLevel), "default level should be info")
+
+	_, err = New("verbose", FormatJSON)
+	assert.NotNil(t, err, "invalid level should fail")
+}
+
+func TestFromContext(t *testing.T) {
+	logger := zap.NewExample()
+	ctx := WithContext(context.Background(), logger)
+
+	assert.Same(t, logger, FromContext(ctx), "logger should come from context")
+	assert.Same(t, zap.L(), FromContext(context.Background()), "global logger should be the fallback")
+}
@@ -0,0 +1,89 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+var ErrUnknownAlgorithm = errors.New("unknown password hash algorithm")
+
+// Hasher gera e confere hashes no formato PHC ($<algoritmo>$...), em que o
+// próprio hash identifica o algoritmo e os parâmetros usados.
+type Hasher interface {
+	Algorithm() string
+	Hash(password string) (string, error)
+	Verify(encoded, password string) (bool, error)
+	// NeedsRehash indica se o hash foi gerado com parâmetros diferentes dos
+	// configurados.
+	NeedsRehash(encoded string) bool
+}
+
+// Identify devolve o algoritmo de um hash. O bcrypt usa os prefixos $2a$,
+// $2b$ e $2y$ em vez do nome.
+func Identify(encoded string) string {
+	parts := strings.SplitN(encoded, "$", 3)
+	if len(parts) < 3 || parts[0] != "" {
+		return ""
+	}
+	switch parts[1] {
+	case "2a", "2b", "2y":
+		return AlgorithmBcrypt
+	case AlgorithmArgon2id:
+		return AlgorithmArgon2id
+	}
+	return ""
+}
+
+// Service gera novos hashes com o algoritmo atual e confere hashes de
+// qualquer algoritmo registrado, para que algoritmos antigos convivam com o
+// novo até que cada senha seja refeita.
+type Service struct {
+	current Hasher
+	hashers map[string]Hasher
+
+	dummyOnce sync.Once
+	dummy     string
+}
+
+func NewService(current Hasher, others ...Hasher) *Service {
+	s := &Service{current: current, hashers: map[string]Hasher{}}
+	for _, hasher := range others {
+		s.hashers[hasher.Algorithm()] = hasher
+	}
+	s.hashers[current.Algorithm()] = current
+	return s
+}
+
+func (s *Service) Hash(password string) (string, error) {
+	return s.current.Hash(password)
+}
+
+// Verify confere a senha e indica se o hash deve ser refeito com o
+// algoritmo e os parâmetros atuais.
+func (s *Service) Verify(encoded, password string) (ok bool, rehash bool, err error) {
+	hasher, found := s.hashers[Identify(encoded)]
+	if !found {
+		return false, false, ErrUnknownAlgorithm
+	}
+	ok, err = hasher.Verify(encoded, password)
+	if err != nil || !ok {
+		return false, false, err
+	}
+	rehash = hasher.Algorithm() != s.current.Algorithm() || hasher.NeedsRehash(encoded)
+	return true, rehash, nil
+}
+
+// VerifyDummy consome o mesmo tempo de Verify com um hash do algoritmo
+// atual, para quando não há hash a conferir.
+func (s *Service) VerifyDummy(password string) {
+	s.dummyOnce.Do(func() {
+		s.dummy, _ = s.current.Hash("dummy password")
+	})
+	_, _ = s.current.Verify(s.dummy, password)
+}
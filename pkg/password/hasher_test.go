@@ -0,0 +1,78 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Parâmetros baixos para manter os testes rápidos.
+func testArgon2id() *Argon2id {
+	return NewArgon2id(1024, 1, 1)
+}
+
+func TestArgon2id(t *testing.T) {
+	hasher := testArgon2id()
+	encoded, err := hasher.Hash("correct horse")
+	assert.Nil(t, err, "error should be nil")
+	assert.Regexp(t, `^\$argon2id\$v=19\$m=1024,t=1,p=1\$[^$]+\$[^$]+$`, encoded)
+	assert.Equal(t, AlgorithmArgon2id, Identify(encoded))
+
+	ok, err := hasher.Verify(encoded, "correct horse")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, err = hasher.Verify(encoded, "wrong horse")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	other, _ := hasher.Hash("correct horse")
+	assert.NotEqual(t, encoded, other, "salt should be random")
+
+	assert.False(t, hasher.NeedsRehash(encoded))
+	assert.True(t, NewArgon2id(2048, 1, 1).NeedsRehash(encoded), "different parameters should require rehash")
+
+	_, err = hasher.Verify("$argon2id$v=19$m=x$salt$key", "correct horse")
+	assert.Equal(t, ErrInvalidHash, err)
+}
+
+func TestBcrypt(t *testing.T) {
+	hasher := NewBcrypt(bcrypt.MinCost)
+	encoded, err := hasher.Hash("correct horse")
+	assert.Nil(t, err, "error should be nil")
+	assert.Equal(t, AlgorithmBcrypt, Identify(encoded))
+
+	ok, err := hasher.Verify(encoded, "correct horse")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, err = hasher.Verify(encoded, "wrong horse")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	assert.False(t, hasher.NeedsRehash(encoded))
+	assert.True(t, NewBcrypt(bcrypt.MinCost+1).NeedsRehash(encoded), "different cost should require rehash")
+}
+
+func TestService_Verify(t *testing.T) {
+	legacy := NewBcrypt(bcrypt.MinCost)
+	bcryptHash, _ := legacy.Hash("correct horse")
+
+	service := NewService(testArgon2id(), legacy)
+	ok, rehash, err := service.Verify(bcryptHash, "correct horse")
+	assert.Nil(t, err)
+	assert.True(t, ok, "hashes of other registered algorithms should be accepted")
+	assert.True(t, rehash, "hashes of other algorithms should be rehashed")
+
+	ok, rehash, _ = service.Verify(bcryptHash, "wrong horse")
+	assert.False(t, ok)
+	assert.False(t, rehash, "wrong passwords should never trigger a rehash")
+
+	argonHash, _ := service.Hash("correct horse")
+	ok, rehash, err = service.Verify(argonHash, "correct horse")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.False(t, rehash, "current hashes should be kept")
+
+	_, _, err = service.Verify("$scrypt$ln=15$salt$key", "correct horse")
+	assert.Equal(t, ErrUnknownAlgorithm, err)
+}
@@ -0,0 +1,87 @@
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+const DefaultMinLength = 8
+
+var (
+	ErrTooShort      = errors.New("password is too short")
+	ErrBreached      = errors.New("password is too common")
+	ErrContainsEmail = errors.New("password must not be the email")
+)
+
+// commonPasswords é a lista padrão de senhas vazadas, usada quando nenhuma
+// lista é configurada.
+//
+//go:embed common_passwords.txt
+var commonPasswords string
+
+// Policy define as regras para novas senhas.
+type Policy struct {
+	MinLength int
+	Breached  map[string]struct{}
+}
+
+func NewPolicy(minLength int) *Policy {
+	if minLength <= 0 {
+		minLength = DefaultMinLength
+	}
+	return &Policy{
+		MinLength: minLength,
+		Breached:  parseList(strings.NewReader(commonPasswords)),
+	}
+}
+
+// LoadBreachedList substitui a lista de senhas vazadas pelo arquivo em path,
+// com uma senha por linha.
+func (p *Policy) LoadBreachedList(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	p.Breached = parseList(file)
+	return nil
+}
+
+// Validate confere a senha de um usuário com o e-mail informado.
+func (p *Policy) Validate(password, email string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return ErrTooShort
+	}
+
+	lower := strings.ToLower(password)
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email != "" {
+		local, _, _ := strings.Cut(email, "@")
+		if lower == email || lower == local {
+			return ErrContainsEmail
+		}
+	}
+
+	if _, ok := p.Breached[lower]; ok {
+		return ErrBreached
+	}
+	return nil
+}
+
+func parseList(r io.Reader) map[string]struct{} {
+	list := map[string]struct{}{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if line != "" && !strings.HasPrefix(line, "#") {
+			list[line] = struct{}{}
+		}
+	}
+	return list
+}
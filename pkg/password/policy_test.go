@@ -0,0 +1,40 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_Validate(t *testing.T) {
+	policy := NewPolicy(0)
+	assert.Equal(t, DefaultMinLength, policy.MinLength)
+
+	tests := []struct {
+		password string
+		err      error
+	}{
+		{"correct horse", nil},
+		{"short", ErrTooShort},
+		{"Password123", ErrBreached},
+		{"john.doe@example.com", ErrContainsEmail},
+		{"JOHN.DOE", ErrContainsEmail},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.err, policy.Validate(tt.password, "john.doe@example.com"), tt.password)
+	}
+}
+
+func TestPolicy_LoadBreachedList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	assert.Nil(t, os.WriteFile(path, []byte("# comment\ncorrect horse\n\n"), 0o600))
+
+	policy := NewPolicy(8)
+	assert.Nil(t, policy.LoadBreachedList(path))
+	assert.Equal(t, ErrBreached, policy.Validate("Correct Horse", ""))
+	assert.Nil(t, policy.Validate("password123", ""), "the loaded list should replace the default one")
+
+	assert.NotNil(t, policy.LoadBreachedList(filepath.Join(t.TempDir(), "missing.txt")))
+}
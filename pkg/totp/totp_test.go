@@ -0,0 +1,58 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Vetores de teste SHA-1 do apêndice B da RFC 6238, truncados para 6 dígitos.
+func TestCodeRFC6238(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		code, err := Code(secret, Step(time.Unix(tt.unix, 0)))
+		assert.Nil(t, err, "error should be nil")
+		assert.Equal(t, tt.code, code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.Nil(t, err, "error should be nil")
+	now := time.Now()
+	code, _ := Code(secret, Step(now))
+
+	step, ok := Validate(secret, code, now, 1)
+	assert.True(t, ok, "current code should be valid")
+	assert.Equal(t, Step(now), step)
+
+	_, ok = Validate(secret, code, now.Add(Period), 1)
+	assert.True(t, ok, "previous code should be accepted within skew")
+
+	_, ok = Validate(secret, code, now.Add(3*Period), 1)
+	assert.False(t, ok, "old code should be rejected")
+
+	_, ok = Validate(secret, "12345", now, 1)
+	assert.False(t, ok, "short code should be rejected")
+}
+
+func TestURI(t *testing.T) {
+	uri := URI("products-api", "user@example.com", "SECRET")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/products-api:user@example.com?"))
+	assert.Contains(t, uri, "secret=SECRET")
+	assert.Contains(t, uri, "issuer=products-api")
+}